@@ -0,0 +1,329 @@
+package hclog
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errAsyncWriterClosed is returned when writing to a closed AsyncWriter
+var errAsyncWriterClosed = errors.New("async writer is closed")
+
+// AsyncPolicy decides what an AsyncWriter does with a line when its queue is
+// full.
+type AsyncPolicy uint8
+
+const (
+	// AsyncBlock waits for there to be room in the queue, so no lines are
+	// lost, but logging is slowed down to the speed of the output.
+	AsyncBlock AsyncPolicy = iota
+	// AsyncDropOldest drops the oldest line in the queue to make room for
+	// the new one.
+	AsyncDropOldest
+	// AsyncDropNewest drops the new line, keeping the queue as it is.
+	AsyncDropNewest
+)
+
+// AsyncWriterOptions can be used to configure an AsyncWriter.
+type AsyncWriterOptions struct {
+	// The number of lines that can be waiting to be written. Defaults to
+	// 1024.
+	QueueSize int
+
+	// What to do when the queue is full. Defaults to AsyncBlock.
+	Policy AsyncPolicy
+
+	// How often the number of dropped lines is reported to the Logger given
+	// to ReportDrops. Defaults to one minute.
+	ReportInterval time.Duration
+}
+
+// AsyncWriter is an io.Writer that queues the lines written to it and writes
+// them to another io.Writer from a background goroutine. Using it as the
+// Output for a Logger means that logging does not wait for a slow disk or
+// pipe, at the cost of possibly dropping lines when the queue is full,
+// depending on the Policy.
+//
+// If the underlying writer is a LevelWriter or NamedLevelWriter, the level
+// and logger name of each line are passed through. Flush waits for the lines
+// queued before it was called, and Close must be called on shutdown so that
+// no lines are lost.
+type AsyncWriter struct {
+	// These are accessed atomically, so are kept first to ensure 64 bit
+	// alignment on 32 bit platforms.
+	dropped  uint64
+	reported uint64
+	queued   uint64
+
+	w              io.Writer
+	policy         AsyncPolicy
+	reportInterval time.Duration
+
+	queue chan asyncLine
+
+	// closeMu is held for reading while enqueuing, so that Close can safely
+	// close the queue.
+	closeMu   sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+
+	// Lines are numbered as they enter the queue, under enqueueMu so that
+	// the numbers follow the order of the queue, and queued is the number of
+	// the last one. done is the number of the last line written or dropped
+	// from the queue. Flush waits on progressed for done to reach the line
+	// queued last when it was called, so lines queued after it don't hold it
+	// up.
+	enqueueMu  sync.Mutex
+	doneMu     sync.Mutex
+	done       uint64
+	progressed *sync.Cond
+
+	reportMu     sync.Mutex
+	reportLogger Logger
+
+	stopReports chan struct{}
+	wg          sync.WaitGroup
+}
+
+type asyncLine struct {
+	seq     uint64
+	name    string
+	level   Level
+	leveled bool
+	named   bool
+	p       []byte
+}
+
+var (
+	_ Flushable        = &AsyncWriter{}
+	_ LevelWriter      = &AsyncWriter{}
+	_ NamedLevelWriter = &AsyncWriter{}
+)
+
+// NewAsyncWriter returns an AsyncWriter that writes to w in the background.
+func NewAsyncWriter(w io.Writer, opts *AsyncWriterOptions) *AsyncWriter {
+	if opts == nil {
+		opts = &AsyncWriterOptions{}
+	}
+
+	size := opts.QueueSize
+	if size <= 0 {
+		size = 1024
+	}
+
+	interval := opts.ReportInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	aw := &AsyncWriter{
+		w:              w,
+		policy:         opts.Policy,
+		reportInterval: interval,
+		queue:          make(chan asyncLine, size),
+		stopReports:    make(chan struct{}),
+	}
+	aw.progressed = sync.NewCond(&aw.doneMu)
+
+	aw.wg.Add(2)
+	go aw.run()
+	go aw.runReports()
+
+	return aw
+}
+
+// Write queues a copy of p to be written.
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	return aw.enqueue(asyncLine{p: p})
+}
+
+// LevelWrite queues a copy of p to be written at the given level.
+func (aw *AsyncWriter) LevelWrite(level Level, p []byte) (int, error) {
+	return aw.enqueue(asyncLine{level: level, leveled: true, p: p})
+}
+
+// NamedLevelWrite queues a copy of p to be written at the given level, for
+// the named logger.
+func (aw *AsyncWriter) NamedLevelWrite(name string, level Level, p []byte) (int, error) {
+	return aw.enqueue(asyncLine{name: name, level: level, leveled: true, named: true, p: p})
+}
+
+func (aw *AsyncWriter) enqueue(line asyncLine) (int, error) {
+	n := len(line.p)
+
+	// The caller is free to reuse p once we return.
+	line.p = append([]byte(nil), line.p...)
+
+	aw.closeMu.RLock()
+	defer aw.closeMu.RUnlock()
+
+	if aw.closed {
+		return 0, errAsyncWriterClosed
+	}
+
+	aw.enqueueMu.Lock()
+	defer aw.enqueueMu.Unlock()
+
+	line.seq = atomic.LoadUint64(&aw.queued) + 1
+
+	switch aw.policy {
+	case AsyncDropNewest:
+		select {
+		case aw.queue <- line:
+		default:
+			atomic.AddUint64(&aw.dropped, 1)
+			return n, nil
+		}
+	case AsyncDropOldest:
+	send:
+		for {
+			select {
+			case aw.queue <- line:
+				break send
+			default:
+			}
+
+			select {
+			case old := <-aw.queue:
+				atomic.AddUint64(&aw.dropped, 1)
+				aw.complete(old.seq)
+			default:
+			}
+		}
+	default:
+		aw.queue <- line
+	}
+
+	atomic.StoreUint64(&aw.queued, line.seq)
+
+	return n, nil
+}
+
+// complete records that the line numbered seq, and so every line queued
+// before it, has been written or dropped.
+func (aw *AsyncWriter) complete(seq uint64) {
+	aw.doneMu.Lock()
+	if seq > aw.done {
+		aw.done = seq
+		aw.progressed.Broadcast()
+	}
+	aw.doneMu.Unlock()
+}
+
+// run writes the queued lines until the queue is closed.
+func (aw *AsyncWriter) run() {
+	defer aw.wg.Done()
+
+	nw, isNamedLevelWriter := aw.w.(NamedLevelWriter)
+	lw, isLevelWriter := aw.w.(LevelWriter)
+
+	for line := range aw.queue {
+		switch {
+		case line.named && isNamedLevelWriter:
+			nw.NamedLevelWrite(line.name, line.level, line.p)
+		case line.leveled && isLevelWriter:
+			lw.LevelWrite(line.level, line.p)
+		default:
+			aw.w.Write(line.p)
+		}
+		aw.complete(line.seq)
+	}
+}
+
+// runReports periodically reports dropped lines until Close is called.
+func (aw *AsyncWriter) runReports() {
+	defer aw.wg.Done()
+
+	ticker := time.NewTicker(aw.reportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			aw.reportDropped()
+		case <-aw.stopReports:
+			return
+		}
+	}
+}
+
+// reportDropped logs the number of lines dropped since the last report, if
+// there were any and a Logger has been given to ReportDrops.
+func (aw *AsyncWriter) reportDropped() {
+	aw.reportMu.Lock()
+	defer aw.reportMu.Unlock()
+
+	if aw.reportLogger == nil {
+		return
+	}
+
+	dropped := atomic.LoadUint64(&aw.dropped)
+	if dropped == aw.reported {
+		return
+	}
+
+	aw.reportLogger.Warn("async log output dropped lines", "dropped", dropped-aw.reported, "total_dropped", dropped)
+	aw.reported = dropped
+}
+
+// ReportDrops sets the Logger that the number of dropped lines is
+// periodically reported to. This is usually the Logger writing to the
+// AsyncWriter, so the report ends up next to where the lines went missing.
+func (aw *AsyncWriter) ReportDrops(l Logger) {
+	aw.reportMu.Lock()
+	defer aw.reportMu.Unlock()
+
+	aw.reportLogger = l
+}
+
+// Dropped returns the total number of lines dropped because the queue was
+// full.
+func (aw *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&aw.dropped)
+}
+
+// Flush waits until the lines queued so far have been written, then flushes
+// the underlying writer if it is Flushable. Lines queued while it waits
+// aren't waited for.
+func (aw *AsyncWriter) Flush() error {
+	seq := atomic.LoadUint64(&aw.queued)
+
+	aw.doneMu.Lock()
+	for aw.done < seq {
+		aw.progressed.Wait()
+	}
+	aw.doneMu.Unlock()
+
+	if f, ok := aw.w.(Flushable); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+// Close reports any dropped lines, writes out everything still queued and
+// stops the background goroutines. Writes after Close return an error. The
+// underlying writer is flushed, if it is Flushable, but not closed.
+func (aw *AsyncWriter) Close() error {
+	var err error
+
+	aw.closeOnce.Do(func() {
+		close(aw.stopReports)
+		aw.reportDropped()
+
+		aw.closeMu.Lock()
+		aw.closed = true
+		close(aw.queue)
+		aw.closeMu.Unlock()
+
+		aw.wg.Wait()
+
+		if f, ok := aw.w.(Flushable); ok {
+			err = f.Flush()
+		}
+	})
+
+	return err
+}
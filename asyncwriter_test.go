@@ -0,0 +1,316 @@
+package hclog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedWriter blocks each write until it is released, signaling on entered
+// when a write starts.
+type gatedWriter struct {
+	entered chan struct{}
+	release chan struct{}
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	levels  []Level
+	flushed int
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{
+		entered: make(chan struct{}, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	g.entered <- struct{}{}
+	<-g.release
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.Write(p)
+}
+
+func (g *gatedWriter) LevelWrite(level Level, p []byte) (int, error) {
+	g.mu.Lock()
+	g.levels = append(g.levels, level)
+	g.mu.Unlock()
+
+	return g.Write(p)
+}
+
+func (g *gatedWriter) Flush() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.flushed++
+	return nil
+}
+
+func (g *gatedWriter) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	// fill writes "first", waits for it to be taken off the queue by the
+	// background writer, then writes lines until the queue of two is full
+	// and beyond.
+	fill := func(t *testing.T, policy AsyncPolicy) (*AsyncWriter, *gatedWriter) {
+		g := newGatedWriter()
+		aw := NewAsyncWriter(g, &AsyncWriterOptions{
+			QueueSize: 2,
+			Policy:    policy,
+		})
+
+		aw.Write([]byte("first\n"))
+		<-g.entered
+
+		for _, line := range []string{"second\n", "third\n", "fourth\n"} {
+			_, err := aw.Write([]byte(line))
+			require.NoError(t, err)
+		}
+
+		return aw, g
+	}
+
+	// releaseAll lets all writes through the gate
+	releaseAll := func(g *gatedWriter) {
+		close(g.release)
+	}
+
+	t.Run("writes in the background", func(t *testing.T) {
+		g := newGatedWriter()
+		releaseAll(g)
+
+		aw := NewAsyncWriter(g, nil)
+		defer aw.Close()
+
+		logger := New(&LoggerOptions{
+			Output:      aw,
+			DisableTime: true,
+		})
+
+		logger.Info("this is test", "who", "programmer")
+		logger.Warn("this is another test")
+
+		require.NoError(t, aw.Flush())
+		assert.Equal(t, "[INFO]  this is test: who=programmer\n[WARN]  this is another test\n", g.String())
+		assert.Equal(t, []Level{Info, Warn}, g.levels)
+		assert.Equal(t, 1, g.flushed)
+	})
+
+	t.Run("drops the newest lines", func(t *testing.T) {
+		aw, g := fill(t, AsyncDropNewest)
+		assert.Equal(t, uint64(1), aw.Dropped())
+
+		releaseAll(g)
+		require.NoError(t, aw.Close())
+		assert.Equal(t, "first\nsecond\nthird\n", g.String())
+	})
+
+	t.Run("drops the oldest lines", func(t *testing.T) {
+		aw, g := fill(t, AsyncDropOldest)
+		assert.Equal(t, uint64(1), aw.Dropped())
+
+		releaseAll(g)
+		require.NoError(t, aw.Close())
+		assert.Equal(t, "first\nthird\nfourth\n", g.String())
+	})
+
+	t.Run("blocks when full", func(t *testing.T) {
+		g := newGatedWriter()
+		aw := NewAsyncWriter(g, &AsyncWriterOptions{
+			QueueSize: 1,
+		})
+
+		aw.Write([]byte("first\n"))
+		<-g.entered
+		aw.Write([]byte("second\n"))
+
+		done := make(chan struct{})
+		go func() {
+			aw.Write([]byte("third\n"))
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			t.Fatal("write did not block")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		releaseAll(g)
+		<-done
+
+		require.NoError(t, aw.Close())
+		assert.Equal(t, "first\nsecond\nthird\n", g.String())
+		assert.Equal(t, uint64(0), aw.Dropped())
+	})
+
+	t.Run("reports dropped lines", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		g := newGatedWriter()
+		aw := NewAsyncWriter(g, &AsyncWriterOptions{
+			QueueSize:      2,
+			Policy:         AsyncDropNewest,
+			ReportInterval: time.Hour,
+		})
+		aw.ReportDrops(New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+		}))
+
+		aw.Write([]byte("first\n"))
+		<-g.entered
+		for i := 0; i < 5; i++ {
+			aw.Write([]byte("more\n"))
+		}
+
+		releaseAll(g)
+		require.NoError(t, aw.Close())
+
+		assert.Equal(t, "[WARN]  async log output dropped lines: dropped=3 total_dropped=3\n", buf.String())
+	})
+
+	t.Run("reports dropped lines periodically", func(t *testing.T) {
+		var buf syncBuffer
+
+		g := newGatedWriter()
+		aw := NewAsyncWriter(g, &AsyncWriterOptions{
+			QueueSize:      1,
+			Policy:         AsyncDropNewest,
+			ReportInterval: 10 * time.Millisecond,
+		})
+
+		aw.ReportDrops(New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+		}))
+
+		aw.Write([]byte("first\n"))
+		<-g.entered
+		aw.Write([]byte("second\n"))
+		aw.Write([]byte("third\n"))
+
+		require.Eventually(t, func() bool {
+			return strings.Contains(buf.String(), "dropped=1")
+		}, time.Second, 5*time.Millisecond)
+
+		releaseAll(g)
+		require.NoError(t, aw.Close())
+	})
+
+	t.Run("errors after close", func(t *testing.T) {
+		g := newGatedWriter()
+		releaseAll(g)
+
+		aw := NewAsyncWriter(g, nil)
+		require.NoError(t, aw.Close())
+		require.NoError(t, aw.Close())
+
+		_, err := aw.Write([]byte("closed\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("works with ResetOutputWithFlush", func(t *testing.T) {
+		g := newGatedWriter()
+		releaseAll(g)
+
+		aw := NewAsyncWriter(g, nil)
+		defer aw.Close()
+
+		var buf bytes.Buffer
+		logger := New(&LoggerOptions{
+			Output:      aw,
+			DisableTime: true,
+		})
+
+		logger.Info("before")
+		require.NoError(t, logger.(OutputResettable).ResetOutputWithFlush(&LoggerOptions{Output: &buf}, aw))
+		logger.Info("after")
+
+		assert.Equal(t, "[INFO]  before\n", g.String())
+		assert.Equal(t, "[INFO]  after\n", buf.String())
+	})
+
+	t.Run("flushes while lines keep being written", func(t *testing.T) {
+		var buf slowBuffer
+
+		aw := NewAsyncWriter(&buf, nil)
+		defer aw.Close()
+
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					aw.Write([]byte("tick\n"))
+				}
+			}
+		}()
+		defer func() {
+			close(stop)
+			wg.Wait()
+		}()
+
+		aw.Write([]byte("marker\n"))
+
+		flushed := make(chan error, 1)
+		go func() {
+			flushed <- aw.Flush()
+		}()
+
+		select {
+		case err := <-flushed:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Flush didn't return while lines were being written")
+		}
+
+		assert.Contains(t, buf.String(), "marker\n")
+	})
+}
+
+// syncBuffer is a bytes.Buffer that is safe to read while being written to.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+// slowBuffer is a syncBuffer that takes a little while over each write, so
+// that a writer can keep it behind.
+type slowBuffer struct {
+	syncBuffer
+}
+
+func (s *slowBuffer) Write(p []byte) (int, error) {
+	time.Sleep(50 * time.Microsecond)
+	return s.syncBuffer.Write(p)
+}
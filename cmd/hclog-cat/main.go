@@ -0,0 +1,418 @@
+// Command hclog-cat pretty-prints and converts the output of hclog.
+//
+// It reads entries written in the JSON format, or in the plain format, from
+// files or standard input, and writes them again in the colored plain format,
+// as logfmt or as JSON:
+//
+//	hclog-cat [flags] [file ...]
+//
+// The format of each input is detected from its first line. Entries can be
+// filtered by level, module and time, and files can be followed like with
+// tail -f:
+//
+//	kubectl logs my-app | hclog-cat -level warn -module my-app.raft
+//	hclog-cat -f -since 10m /var/log/my-app.log
+//
+// Entries in the plain format may span several lines, so each is written
+// once the line starting the next one has been read or, when following,
+// once no more lines have come for a moment.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TerminusDeus/go-hclog"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// config holds the options given on the command line.
+type config struct {
+	format      hclog.OutputFormat
+	color       hclog.ColorOption
+	level       hclog.Level
+	module      string
+	since       time.Time
+	until       time.Time
+	follow      bool
+	inputTime   string
+	outputTime  string
+	disableTime bool
+}
+
+// run runs the command, returning the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("hclog-cat", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: hclog-cat [flags] [file ...]\n\n")
+		fmt.Fprintf(stderr, "Reads hclog output in the JSON or plain format from the files, or\n")
+		fmt.Fprintf(stderr, "standard input, and writes it in the chosen format.\n\n")
+		fs.PrintDefaults()
+	}
+
+	var (
+		format = fs.String("format", "plain", "output `format`: plain, logfmt or json")
+		color  = fs.String("color", "auto", "color the plain format: auto, always or never")
+		level  = fs.String("level", "trace", "only show entries at this `level` or above")
+		since  = fs.String("since", "", "only show entries from this `time` on, or from this long ago, such as 10m")
+		until  = fs.String("until", "", "only show entries before this `time`, or before this long ago")
+		cfg    = &config{}
+	)
+
+	fs.StringVar(&cfg.module, "module", "", "only show entries from this `module` and the modules below it")
+	fs.BoolVar(&cfg.follow, "f", false, "follow the files as they grow, like tail -f")
+	fs.StringVar(&cfg.inputTime, "input-time-format", hclog.TimeFormat, "the time `layout` of the input")
+	fs.StringVar(&cfg.outputTime, "time-format", hclog.TimeFormat, "the time `layout` of the output")
+	fs.BoolVar(&cfg.disableTime, "no-time", false, "leave the time out of the output")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var err error
+
+	switch *format {
+	case "plain":
+		cfg.format = hclog.FormatPlain
+	case "logfmt":
+		cfg.format = hclog.FormatLogfmt
+	case "json":
+		cfg.format = hclog.FormatJSON
+	default:
+		err = fmt.Errorf("unknown format: %q", *format)
+	}
+
+	switch *color {
+	case "auto":
+		cfg.color = hclog.AutoColor
+	case "always":
+		cfg.color = hclog.ForceColor
+	case "never":
+		cfg.color = hclog.ColorOff
+	default:
+		err = fmt.Errorf("unknown color option: %q", *color)
+	}
+
+	if cfg.level = hclog.LevelFromString(*level); cfg.level == hclog.NoLevel {
+		err = fmt.Errorf("unknown level: %q", *level)
+	}
+
+	now := time.Now()
+	if cfg.since, err = parseTime(*since, now, err); err == nil {
+		cfg.until, err = parseTime(*until, now, err)
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "hclog-cat: %s\n", err)
+		return 2
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		status = 0
+	)
+
+	catNamed := func(name string) {
+		if err := catFile(cfg, name, stdin, stdout, &mu); err != nil {
+			mu.Lock()
+			fmt.Fprintf(stderr, "hclog-cat: %s\n", err)
+			status = 1
+			mu.Unlock()
+		}
+	}
+
+	for _, name := range files {
+		// Followed files never end, so they're read side by side.
+		if cfg.follow && name != "-" {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				catNamed(name)
+			}(name)
+		} else {
+			catNamed(name)
+		}
+	}
+
+	wg.Wait()
+
+	return status
+}
+
+// parseTime reads the time given to -since or -until, which is either a
+// time in RFC 3339 or hclog's format, or a duration before now. It passes
+// on prev if it's an error, so that the first error is reported.
+func parseTime(s string, now time.Time, prev error) (time.Time, error) {
+	if prev != nil || s == "" {
+		return time.Time{}, prev
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, hclog.TimeFormat} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time: %q", s)
+}
+
+// catFile writes the entries of the named file, or of stdin for "-".
+func catFile(cfg *config, name string, stdin io.Reader, stdout io.Writer, mu *sync.Mutex) error {
+	var r io.Reader = stdin
+
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r = f
+		if cfg.follow {
+			r = &followReader{r: f, interval: 250 * time.Millisecond}
+		}
+	}
+
+	return cat(cfg, r, newRenderer(cfg, stdout, mu))
+}
+
+// cat writes the entries read from r, detecting their format from the first
+// line.
+func cat(cfg *config, r io.Reader, out *renderer) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+
+	first, err := br.Peek(1)
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if first[0] != '{' {
+		if cfg.follow {
+			return catFollowPlain(cfg, br, out, followIdle)
+		}
+
+		s := hclog.NewScanner(br, &hclog.ParseOptions{TimeFormat: cfg.inputTime})
+		for s.Scan() {
+			out.render(s.Record())
+		}
+		return s.Err()
+	}
+
+	lines := bufio.NewScanner(br)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for lines.Scan() {
+		line := lines.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		rec, err := parseJSON(line, cfg.inputTime)
+		if err != nil {
+			// Pass on anything else written to the output, such as a
+			// panic.
+			out.raw(line)
+			continue
+		}
+
+		out.render(rec)
+	}
+
+	return lines.Err()
+}
+
+// followIdle is how long to wait for more lines of an entry in the plain
+// format while following, before writing it.
+const followIdle = 500 * time.Millisecond
+
+// catFollowPlain writes the entries in the plain format read from r, which
+// may never end. As the last entry read so far can only be known to be
+// complete once the next one starts, it's written once no more lines have
+// been read for idle.
+func catFollowPlain(cfg *config, r io.Reader, out *renderer, idle time.Duration) error {
+	opts := &hclog.ParseOptions{TimeFormat: cfg.inputTime}
+
+	lines := make(chan string)
+	errc := make(chan error, 1)
+
+	go func() {
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 64*1024), 1024*1024)
+
+		for s.Scan() {
+			lines <- s.Text()
+		}
+
+		errc <- s.Err()
+		close(lines)
+	}()
+
+	var (
+		pending []string
+		started bool
+	)
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+
+		rec, err := hclog.Parse(strings.Join(pending, "\n"), opts)
+		if err == nil {
+			out.render(rec)
+		}
+		pending = pending[:0]
+	}
+
+	for {
+		var timeout <-chan time.Time
+		if len(pending) > 0 {
+			timeout = time.After(idle)
+		}
+
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				flush()
+				return <-errc
+			}
+
+			switch {
+			case isEntryStart(line, opts):
+				flush()
+				pending = append(pending, line)
+				started = true
+			case len(pending) > 0:
+				pending = append(pending, line)
+			case started:
+				// The rest of an entry that was already written, as it
+				// came too late.
+				out.raw([]byte(line))
+			}
+		case <-timeout:
+			flush()
+		}
+	}
+}
+
+// isEntryStart reports whether line is the first line of an entry in the
+// plain format.
+func isEntryStart(line string, opts *hclog.ParseOptions) bool {
+	_, err := hclog.Parse(line, opts)
+	return err == nil
+}
+
+// jsonKeys are the keys of the fields of an entry in the JSON format. The
+// keys with an @ are those written by hashicorp/go-hclog.
+var jsonKeys = map[string]string{
+	"message":    "message",
+	"@message":   "message",
+	"level":      "level",
+	"@level":     "level",
+	"module":     "module",
+	"@module":    "module",
+	"timestamp":  "timestamp",
+	"@timestamp": "timestamp",
+	"caller":     "caller",
+	"@caller":    "caller",
+	"stacktrace": "stacktrace",
+}
+
+// parseJSON reads an entry written in the JSON format. The other fields are
+// returned as the args, sorted by key as hclog writes them.
+func parseJSON(line []byte, timeFormat string) (*hclog.Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	var vals map[string]interface{}
+	if err := dec.Decode(&vals); err != nil {
+		return nil, err
+	}
+
+	msg, ok := vals["message"].(string)
+	if !ok {
+		if msg, ok = vals["@message"].(string); !ok {
+			return nil, errors.New("entry has no message")
+		}
+	}
+
+	rec := &hclog.Record{Message: msg}
+
+	var keys []string
+
+	for key, val := range vals {
+		s, _ := val.(string)
+
+		switch jsonKeys[key] {
+		case "message":
+		case "level":
+			rec.Level = hclog.LevelFromString(s)
+		case "module":
+			rec.Name = s
+		case "timestamp":
+			for _, layout := range []string{timeFormat, time.RFC3339Nano} {
+				if t, err := time.Parse(layout, s); err == nil {
+					rec.Time = t
+					break
+				}
+			}
+		case "caller":
+			rec.Caller = parseCaller(s)
+		case "stacktrace":
+			rec.Stacktrace = hclog.CapturedStacktrace(s)
+		default:
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		rec.Args = append(rec.Args, key, vals[key])
+	}
+
+	return rec, nil
+}
+
+// parseCaller reads a location written as file:line.
+func parseCaller(s string) *runtime.Frame {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return nil
+	}
+
+	line, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return nil
+	}
+
+	return &runtime.Frame{File: s[:i], Line: line}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jsonInput = `{"level":"info","message":"started","module":"app","timestamp":"2021-03-04T05:06:07.000-0700","port":8080}
+{"caller":"app/db.go:12","level":"warn","message":"slow query","module":"app.db","timestamp":"2021-03-04T05:07:07.000-0700","took":"1.5s"}
+panic: something went wrong
+{"@level":"error","@message":"legacy","@module":"old","@timestamp":"2021-03-04T05:08:07.000000-07:00","stacktrace":"main.main\n\t/src/main.go:10"}
+`
+
+func runCat(t *testing.T, input string, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+
+	status := run(append([]string{"-color", "never"}, args...), strings.NewReader(input), &stdout, &stderr)
+	return stdout.String(), stderr.String(), status
+}
+
+func TestRun(t *testing.T) {
+	t.Run("converts JSON to the plain format", func(t *testing.T) {
+		out, _, status := runCat(t, jsonInput)
+		assert.Equal(t, 0, status)
+
+		assert.Equal(t, strings.Join([]string{
+			"2021-03-04T05:06:07.000-0700 [INFO]  app: started: port=8080",
+			"2021-03-04T05:07:07.000-0700 [WARN]  app.db: slow query: caller=app/db.go:12 took=1.5s",
+			"panic: something went wrong",
+			"2021-03-04T05:08:07.000-0700 [ERROR] old: legacy:",
+			"main.main",
+			"\t/src/main.go:10",
+			"",
+		}, "\n"), out)
+	})
+
+	t.Run("converts the plain format to JSON", func(t *testing.T) {
+		plain, _, _ := runCat(t, jsonInput)
+
+		out, _, status := runCat(t, plain, "-format", "json", "-module", "app")
+		assert.Equal(t, 0, status)
+
+		assert.Equal(t, strings.Join([]string{
+			`{"level":"info","message":"started","module":"app","port":"8080","timestamp":"2021-03-04T05:06:07.000-0700"}`,
+			`{"caller":"app/db.go:12","level":"warn","message":"slow query","module":"app.db","stacktrace":"panic: something went wrong","timestamp":"2021-03-04T05:07:07.000-0700","took":"1.5s"}`,
+			"",
+		}, "\n"), out)
+	})
+
+	t.Run("converts to logfmt", func(t *testing.T) {
+		out, _, _ := runCat(t, jsonInput, "-format", "logfmt", "-no-time", "-module", "app.db")
+
+		assert.Equal(t, "level=warn module=app.db msg=\"slow query\" caller=app/db.go:12 took=1.5s\npanic: something went wrong\n", out)
+	})
+
+	t.Run("filters by level", func(t *testing.T) {
+		out, _, _ := runCat(t, jsonInput, "-level", "warn", "-no-time")
+
+		assert.Equal(t, strings.Join([]string{
+			"[WARN]  app.db: slow query: caller=app/db.go:12 took=1.5s",
+			"panic: something went wrong",
+			"[ERROR] old: legacy:",
+			"main.main",
+			"\t/src/main.go:10",
+			"",
+		}, "\n"), out)
+	})
+
+	t.Run("filters by time", func(t *testing.T) {
+		out, _, _ := runCat(t, jsonInput, "-no-time", "-since", "2021-03-04T05:07:00-07:00", "-until", "2021-03-04T12:08:07Z")
+
+		assert.Equal(t, "[WARN]  app.db: slow query: caller=app/db.go:12 took=1.5s\npanic: something went wrong\n", out)
+
+		out, _, _ = runCat(t, jsonInput, "-since", "1h")
+		assert.Equal(t, "panic: something went wrong\n", out)
+	})
+
+	t.Run("reads files", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "hclog-cat")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "app.log")
+		require.NoError(t, ioutil.WriteFile(path, []byte(jsonInput), 0644))
+
+		out, stderr, status := runCat(t, "", "-no-time", "-module", "old", path, filepath.Join(dir, "missing.log"))
+		assert.Equal(t, 1, status)
+		assert.Equal(t, "panic: something went wrong\n[ERROR] old: legacy:\nmain.main\n\t/src/main.go:10\n", out)
+		assert.Contains(t, stderr, "missing.log")
+	})
+
+	t.Run("filters by module", func(t *testing.T) {
+		input := jsonInput + `{"level":"info","message":"other app","module":"application"}` + "\n"
+
+		out, _, _ := runCat(t, input, "-no-time", "-module", "app")
+		assert.Equal(t, strings.Join([]string{
+			"[INFO]  app: started: port=8080",
+			"[WARN]  app.db: slow query: caller=app/db.go:12 took=1.5s",
+			"panic: something went wrong",
+			"",
+		}, "\n"), out)
+
+		out, _, _ = runCat(t, input, "-no-time", "-module", "app.d")
+		assert.Equal(t, "panic: something went wrong\n", out)
+	})
+
+	t.Run("writes the last plain entry while following", func(t *testing.T) {
+		r, w := io.Pipe()
+
+		var stdout, stderr syncBuffer
+
+		done := make(chan int)
+		go func() {
+			done <- run([]string{"-color", "never", "-no-time", "-f"}, r, &stdout, &stderr)
+		}()
+
+		io.WriteString(w, "2021-03-04T05:06:07.000-0700 [INFO]  app: started: port=8080\n")
+		io.WriteString(w, "2021-03-04T05:07:07.000-0700 [ERROR] app: failed:\nmain.main\n")
+
+		// The last entry is written once no more lines have come, without
+		// waiting for the next one to start.
+		require.Eventually(t, func() bool {
+			return strings.Contains(stdout.String(), "main.main")
+		}, 5*time.Second, 10*time.Millisecond)
+
+		w.Close()
+		assert.Equal(t, 0, <-done)
+
+		assert.Equal(t, "[INFO]  app: started: port=8080\n[ERROR] app: failed:\nmain.main\n", stdout.String())
+	})
+
+	t.Run("rejects invalid flags", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"-format", "xml"},
+			{"-color", "sometimes"},
+			{"-level", "loud"},
+			{"-since", "yesterday"},
+		} {
+			_, stderr, status := runCat(t, "", args...)
+			assert.Equal(t, 2, status, args)
+			assert.Contains(t, stderr, "hclog-cat: ", args)
+		}
+	})
+}
+
+// syncBuffer is a bytes.Buffer that can be read while it's written to.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestFollowReader(t *testing.T) {
+	f, err := ioutil.TempFile("", "hclog-cat")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	rf, err := os.Open(f.Name())
+	require.NoError(t, err)
+	defer rf.Close()
+
+	r := &followReader{r: rf, interval: time.Millisecond}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		f.WriteString("more")
+	}()
+
+	// Reading waits at the end of the file until it has grown.
+	buf := make([]byte, 16)
+
+	n, err := r.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "more", string(buf[:n]))
+}
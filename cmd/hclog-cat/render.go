@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TerminusDeus/go-hclog"
+)
+
+// renderer writes entries through an hclog.Logger, so that they are
+// formatted exactly as hclog would have written them.
+type renderer struct {
+	cfg *config
+	out io.Writer
+	mu  *sync.Mutex
+
+	// now is the time of the entry being written, returned by the TimeFn of
+	// the logger.
+	now time.Time
+
+	root  hclog.Logger
+	named map[string]hclog.Logger
+}
+
+// newRenderer returns a renderer writing to out. Renderers sharing mu can
+// write to out at the same time.
+func newRenderer(cfg *config, out io.Writer, mu *sync.Mutex) *renderer {
+	r := &renderer{
+		cfg:   cfg,
+		out:   out,
+		mu:    mu,
+		named: map[string]hclog.Logger{},
+	}
+
+	r.root = hclog.New(&hclog.LoggerOptions{
+		Level:        cfg.level,
+		Output:       out,
+		Mutex:        mu,
+		OutputFormat: cfg.format,
+		Color:        cfg.color,
+		TimeFormat:   cfg.outputTime,
+		DisableTime:  cfg.disableTime,
+		TimeFn:       func() time.Time { return r.now },
+	})
+
+	return r
+}
+
+// render writes rec, unless it's filtered out.
+func (r *renderer) render(rec *hclog.Record) {
+	if !matchModule(rec.Name, r.cfg.module) {
+		return
+	}
+
+	if !r.cfg.since.IsZero() || !r.cfg.until.IsZero() {
+		if rec.Time.IsZero() ||
+			(!r.cfg.since.IsZero() && rec.Time.Before(r.cfg.since)) ||
+			(!r.cfg.until.IsZero() && !rec.Time.Before(r.cfg.until)) {
+			return
+		}
+	}
+
+	args := make([]interface{}, 0, len(rec.Args)+3)
+
+	// The location can't be passed to the logger, so is written as a field
+	// with the same key as the JSON format uses.
+	if rec.Caller != nil {
+		args = append(args, "caller", fmt.Sprintf("%s:%d", rec.Caller.File, rec.Caller.Line))
+	}
+
+	args = append(args, rec.Args...)
+
+	if rec.Stacktrace != "" {
+		args = append(args, rec.Stacktrace)
+	}
+
+	// Entries with an unknown level are shown at INFO, rather than being
+	// dropped by the logger.
+	level := rec.Level
+	if level == hclog.NoLevel {
+		level = hclog.Info
+	}
+
+	r.now = rec.Time
+	r.logger(rec.Name).Log(level, rec.Message, args...)
+}
+
+// matchModule reports whether name is module or a module below it, such as
+// "app.db" for "app". An empty module matches every name.
+func matchModule(name, module string) bool {
+	if module == "" || name == module {
+		return true
+	}
+
+	return strings.HasPrefix(name, module+".")
+}
+
+// raw writes a line that isn't an entry as it is.
+func (r *renderer) raw(line []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.out.Write(line)
+	io.WriteString(r.out, "\n")
+}
+
+// logger returns the logger for the module name.
+func (r *renderer) logger(name string) hclog.Logger {
+	l, ok := r.named[name]
+	if !ok {
+		l = r.root.ResetNamed(name)
+		r.named[name] = l
+	}
+
+	return l
+}
+
+// followReader reads a file as it grows, waiting for more data at the end
+// of it instead of returning io.EOF.
+type followReader struct {
+	r        io.Reader
+	interval time.Duration
+}
+
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+
+		time.Sleep(f.interval)
+	}
+}
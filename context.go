@@ -2,6 +2,9 @@ package hclog
 
 import (
 	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
 )
 
 // WithContext inserts a logger into the context and is retrievable
@@ -31,6 +34,128 @@ func FromContext(ctx context.Context) Logger {
 	return logger
 }
 
+// ContextExtractor returns key/value pairs to add to entries logged with a
+// context, such as a request ID stored in it by middleware. It should return
+// nil if the context doesn't hold anything of interest.
+type ContextExtractor func(ctx context.Context) []interface{}
+
+var (
+	// contextExtractors holds the registered []ContextExtractor. It is
+	// replaced as a whole on every registration, so it can be read without
+	// locking. contextExtractorsMu serializes the registrations.
+	contextExtractors   atomic.Value
+	contextExtractorsMu sync.Mutex
+)
+
+// RegisterContextExtractor adds fn to the extractors used by ContextArgs, and
+// so by LogCtx, InfoCtx and the other *Ctx functions. It's meant to be called
+// during program initialization:
+//
+//	func init() {
+//		hclog.RegisterContextExtractor(hclog.ContextValue("request_id", requestIDKey{}))
+//	}
+func RegisterContextExtractor(fn ContextExtractor) {
+	contextExtractorsMu.Lock()
+	defer contextExtractorsMu.Unlock()
+
+	current, _ := contextExtractors.Load().([]ContextExtractor)
+
+	extractors := make([]ContextExtractor, 0, len(current)+1)
+	extractors = append(extractors, current...)
+	extractors = append(extractors, fn)
+
+	contextExtractors.Store(extractors)
+}
+
+// ContextValue returns a ContextExtractor that adds the value stored in the
+// context under ctxKey, with the given key, if there is one.
+func ContextValue(key string, ctxKey interface{}) ContextExtractor {
+	return func(ctx context.Context) []interface{} {
+		v := ctx.Value(ctxKey)
+		if v == nil {
+			return nil
+		}
+
+		return []interface{}{key, v}
+	}
+}
+
+// ContextArgs returns the key/value pairs that the registered extractors
+// pull from ctx, in the order the extractors were registered.
+func ContextArgs(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+
+	extractors, _ := contextExtractors.Load().([]ContextExtractor)
+
+	var args []interface{}
+	for _, fn := range extractors {
+		args = append(args, fn(ctx)...)
+	}
+
+	return args
+}
+
+// LogCtx emits the message and args at the given level with the logger from
+// the context, as returned by FromContext, adding the key/value pairs from
+// the registered extractors ahead of args.
+func LogCtx(ctx context.Context, level Level, msg string, args ...interface{}) {
+	logCtx(ctx, level, msg, args)
+}
+
+// TraceCtx is like LogCtx at TRACE level.
+func TraceCtx(ctx context.Context, msg string, args ...interface{}) {
+	logCtx(ctx, Trace, msg, args)
+}
+
+// DebugCtx is like LogCtx at DEBUG level.
+func DebugCtx(ctx context.Context, msg string, args ...interface{}) {
+	logCtx(ctx, Debug, msg, args)
+}
+
+// InfoCtx is like LogCtx at INFO level.
+func InfoCtx(ctx context.Context, msg string, args ...interface{}) {
+	logCtx(ctx, Info, msg, args)
+}
+
+// WarnCtx is like LogCtx at WARN level.
+func WarnCtx(ctx context.Context, msg string, args ...interface{}) {
+	logCtx(ctx, Warn, msg, args)
+}
+
+// ErrorCtx is like LogCtx at ERROR level.
+func ErrorCtx(ctx context.Context, msg string, args ...interface{}) {
+	logCtx(ctx, Error, msg, args)
+}
+
+// logCtx must be called directly by the exported *Ctx functions, so that the
+// caller of those is found at a fixed depth.
+func logCtx(ctx context.Context, level Level, msg string, args []interface{}) {
+	logger := FromContext(ctx)
+
+	// Don't run the extractors for entries that won't be logged.
+	if !IsLevelEnabled(logger, level) {
+		return
+	}
+
+	if extra := ContextArgs(ctx); len(extra) > 0 {
+		args = append(extra, args...)
+	}
+
+	el, ok := logger.(entryLogger)
+	if !ok {
+		logger.Log(level, msg, args...)
+		return
+	}
+
+	// Skip runtime.Callers, logCtx and the exported function.
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+
+	el.logEntry(logger.Name(), level, msg, pcs[0], args...)
+}
+
 // Unexported new type so that our context key never collides with another.
 type contextKeyType struct{}
 
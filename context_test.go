@@ -3,6 +3,10 @@ package hclog
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -34,3 +38,93 @@ func TestContext_fields(t *testing.T) {
 	l.Debug("test")
 	require.Contains(t, buf.String(), "hello")
 }
+
+type testRequestIDKey struct{}
+
+type testTenantKey struct{}
+
+var registerTestContextExtractors sync.Once
+
+// withTestContextExtractors registers the extractors used by the tests, which
+// can only be done once per process.
+func withTestContextExtractors() {
+	registerTestContextExtractors.Do(func() {
+		RegisterContextExtractor(ContextValue("request_id", testRequestIDKey{}))
+		RegisterContextExtractor(func(ctx context.Context) []interface{} {
+			tenant, ok := ctx.Value(testTenantKey{}).(string)
+			if !ok {
+				return nil
+			}
+			return []interface{}{"tenant", tenant, "tenant_len", len(tenant)}
+		})
+	})
+}
+
+func TestContext_extractors(t *testing.T) {
+	withTestContextExtractors()
+
+	ctx := context.WithValue(context.Background(), testRequestIDKey{}, "req-1")
+	ctx = context.WithValue(ctx, testTenantKey{}, "acme")
+
+	require.Equal(t, []interface{}{"request_id", "req-1", "tenant", "acme", "tenant_len", 4}, ContextArgs(ctx))
+	require.Empty(t, ContextArgs(context.Background()))
+	require.Empty(t, ContextArgs(nil))
+
+	t.Run("adds the fields at log time", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(&LoggerOptions{
+			Name:        "test",
+			Level:       Debug,
+			Output:      &buf,
+			DisableTime: true,
+		})
+
+		ctx := WithContext(ctx, l, "component", "api")
+
+		InfoCtx(ctx, "request handled", "status", 200)
+		TraceCtx(ctx, "not logged")
+		DebugCtx(context.WithValue(ctx, testRequestIDKey{}, "req-2"), "debug")
+		LogCtx(ctx, Warn, "warned")
+		ErrorCtx(WithContext(context.Background(), l), "no fields")
+
+		require.Equal(t, strings.Join([]string{
+			"[INFO]  test: request handled: component=api request_id=req-1 tenant=acme tenant_len=4 status=200",
+			"[DEBUG] test: debug: component=api request_id=req-2 tenant=acme tenant_len=4",
+			"[WARN]  test: warned: component=api request_id=req-1 tenant=acme tenant_len=4",
+			"[ERROR] test: no fields",
+			"",
+		}, "\n"), buf.String())
+	})
+
+	t.Run("reports the location of the caller", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(&LoggerOptions{
+			Output:          &buf,
+			DisableTime:     true,
+			IncludeLocation: true,
+		})
+
+		_, _, line, _ := runtime.Caller(0)
+		WarnCtx(WithContext(ctx, l), "located")
+
+		require.Contains(t, buf.String(), fmt.Sprintf("context_test.go:%d: located: request_id=req-1", line+1))
+	})
+
+	t.Run("reaches the sinks of an intercept logger", func(t *testing.T) {
+		var buf, sbuf bytes.Buffer
+		l := NewInterceptLogger(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+		})
+		l.RegisterSink(NewSinkAdapter(&LoggerOptions{
+			Output:      &sbuf,
+			DisableTime: true,
+		}))
+
+		InfoCtx(WithContext(ctx, l), "intercepted")
+
+		expected := "[INFO]  intercepted: request_id=req-1 tenant=acme tenant_len=4\n"
+		require.Equal(t, expected, buf.String())
+		require.Equal(t, expected, sbuf.String())
+	})
+}
@@ -0,0 +1,144 @@
+package hclog
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// deduper collapses entries that exactly repeat the one before them, in the
+// style of syslog's "last message repeated N times". It's shared by a logger
+// and the loggers derived from it, and guarded by their shared mutex.
+type deduper struct {
+	interval time.Duration
+	mutex    Locker
+
+	// last is the most recent entry written, and repeated the number of
+	// times it has been repeated since, without being written.
+	last     *dedupEntry
+	repeated int
+
+	// timer writes the summary once interval has passed since the first
+	// repeat.
+	timer *time.Timer
+}
+
+// dedupEntry is an entry as passed to write, along with the logger that
+// wrote it, which is used to write the summary.
+type dedupEntry struct {
+	logger  *intLogger
+	name    string
+	level   Level
+	msg     string
+	caller  *runtime.Frame
+	implied []interface{}
+	args    []interface{}
+
+	// line is the entry as encoded without a time, which is what's compared.
+	// Values are rendered when the entry is written, so a value changed
+	// behind a pointer makes a different line.
+	line []byte
+}
+
+func newDeduper(interval time.Duration, mutex Locker) *deduper {
+	return &deduper{
+		interval: interval,
+		mutex:    mutex,
+	}
+}
+
+// repeat reports whether the entry repeats the last one, in which case it is
+// counted instead of being written. Otherwise, the summary for the last entry
+// is written first, if it was repeated. The mutex must be held.
+func (d *deduper) repeat(l *intLogger, name string, level Level, msg string, caller *runtime.Frame, args []interface{}) bool {
+	e := &dedupEntry{
+		logger:  l,
+		name:    name,
+		level:   level,
+		msg:     msg,
+		caller:  caller,
+		implied: l.implied,
+		args:    args,
+	}
+
+	var buf bytes.Buffer
+	if err := l.encoder.Encode(&buf, time.Time{}, name, level, msg, caller, l.implied, args); err == nil {
+		e.line = buf.Bytes()
+	}
+
+	if d.last != nil && d.last.equal(e) {
+		d.repeated++
+
+		if d.repeated == 1 {
+			if d.timer == nil {
+				d.timer = time.AfterFunc(d.interval, d.expire)
+			} else {
+				d.timer.Reset(d.interval)
+			}
+		}
+
+		return true
+	}
+
+	d.flush()
+
+	// The caller may reuse its args slice, so the entry keeps a copy to
+	// compare the following ones against.
+	e.args = append([]interface{}(nil), args...)
+	d.last = e
+
+	return false
+}
+
+// expire writes the summary once the interval has passed.
+func (d *deduper) expire() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.flush()
+}
+
+// flush writes the summary for the last entry, if it has been repeated. The
+// entry is kept, so that further repeats are counted again from zero. The
+// mutex must be held.
+func (d *deduper) flush() {
+	if d.repeated == 0 {
+		return
+	}
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+
+	e, repeated := d.last, d.repeated
+	d.repeated = 0
+
+	l := e.logger
+	t := l.timeFn()
+
+	var err error
+	if _, ok := l.encoder.(*plainEncoder); ok {
+		msg := fmt.Sprintf("last message repeated %d times", repeated)
+		err = l.encoder.Encode(&l.writer.b, t, e.name, e.level, msg, nil, nil, nil)
+	} else {
+		// Structured formats get the entry again, with the count as a
+		// field of its own.
+		implied := make([]interface{}, 0, len(e.implied)+2)
+		implied = append(implied, e.implied...)
+		implied = append(implied, "repeated", repeated)
+
+		err = l.encoder.Encode(&l.writer.b, t, e.name, e.level, e.msg, e.caller, implied, e.args)
+	}
+	if err != nil {
+		l.writer.b.Reset()
+		return
+	}
+
+	l.writer.Flush(e.name, e.level)
+}
+
+// equal reports whether o would be written the same as e.
+func (e *dedupEntry) equal(o *dedupEntry) bool {
+	return e.line != nil && bytes.Equal(e.line, o.line)
+}
@@ -0,0 +1,201 @@
+package hclog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Deduplicate(t *testing.T) {
+	t.Run("collapses repeated lines", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Name:                "test",
+			Output:              &buf,
+			DisableTime:         true,
+			DeduplicateInterval: time.Hour,
+		})
+
+		logger.Info("disk full", "path", "/var")
+		logger.Info("disk full", "path", "/var")
+		logger.Info("disk full", "path", "/var")
+		logger.Info("disk full", "path", "/tmp")
+		logger.Warn("disk full", "path", "/tmp")
+		logger.Warn("disk full", "path", "/tmp")
+		logger.Named("sub").Warn("disk full", "path", "/tmp")
+
+		assert.Equal(t, strings.Join([]string{
+			"[INFO]  test: disk full: path=/var",
+			"[INFO]  test: last message repeated 2 times",
+			"[INFO]  test: disk full: path=/tmp",
+			"[WARN]  test: disk full: path=/tmp",
+			"[WARN]  test: last message repeated 1 times",
+			"[WARN]  test.sub: disk full: path=/tmp",
+			"",
+		}, "\n"), buf.String())
+	})
+
+	t.Run("adds a repeated field in json", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:              &buf,
+			JSONFormat:          true,
+			DeduplicateInterval: time.Hour,
+		}).With("component", "disk")
+
+		for i := 0; i < 4; i++ {
+			logger.Error("disk full", "path", "/var")
+		}
+		require.NoError(t, logger.(Flushable).Flush())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 2)
+
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &raw))
+
+		assert.Equal(t, "disk full", raw["message"])
+		assert.Equal(t, "/var", raw["path"])
+		assert.Equal(t, "disk", raw["component"])
+		assert.Equal(t, float64(3), raw["repeated"])
+	})
+
+	t.Run("compares values that can't be compared directly", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:              &buf,
+			DisableTime:         true,
+			DeduplicateInterval: time.Hour,
+		})
+
+		logger.Info("peers", "addrs", []string{"a", "b"})
+		logger.Info("peers", "addrs", []string{"a", "b"})
+		logger.Info("peers", "addrs", []string{"a", "c"})
+
+		assert.Equal(t, strings.Join([]string{
+			"[INFO]  peers: addrs=[\"a\", \"b\"]",
+			"[INFO]  last message repeated 1 times",
+			"[INFO]  peers: addrs=[\"a\", \"c\"]",
+			"",
+		}, "\n"), buf.String())
+	})
+
+	t.Run("writes the summary after the interval", func(t *testing.T) {
+		var buf syncBuffer
+
+		logger := New(&LoggerOptions{
+			Output:              &buf,
+			DisableTime:         true,
+			DeduplicateInterval: 10 * time.Millisecond,
+		})
+
+		logger.Info("tick")
+		logger.Info("tick")
+		logger.Info("tick")
+
+		require.Eventually(t, func() bool {
+			return strings.Contains(buf.String(), "last message repeated 2 times")
+		}, time.Second, 5*time.Millisecond)
+
+		// Repeats are counted again after the summary.
+		logger.Info("tick")
+		logger.(Flushable).Flush()
+
+		assert.Equal(t, strings.Join([]string{
+			"[INFO]  tick",
+			"[INFO]  last message repeated 2 times",
+			"[INFO]  last message repeated 1 times",
+			"",
+		}, "\n"), buf.String())
+	})
+
+	t.Run("writes the summary to the old output on reset", func(t *testing.T) {
+		var first, second bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:              &first,
+			DisableTime:         true,
+			DeduplicateInterval: time.Hour,
+		})
+
+		logger.Info("tick")
+		logger.Info("tick")
+
+		require.NoError(t, logger.(OutputResettable).ResetOutputWithFlush(&LoggerOptions{
+			Output: &second,
+		}, &bufferingBuffer{}))
+
+		logger.Info("tick")
+		logger.Info("tock")
+
+		assert.Equal(t, "[INFO]  tick\n[INFO]  last message repeated 1 times\n", first.String())
+		assert.Equal(t, "[INFO]  last message repeated 1 times\n[INFO]  tock\n", second.String())
+	})
+
+	t.Run("writes the summary to the old output on reset without flush", func(t *testing.T) {
+		var first, second bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:              &first,
+			DisableTime:         true,
+			DeduplicateInterval: time.Hour,
+		})
+
+		logger.Info("tick")
+		logger.Info("tick")
+
+		require.NoError(t, logger.(OutputResettable).ResetOutput(&LoggerOptions{
+			Output: &second,
+		}))
+
+		logger.Info("tock")
+
+		assert.Equal(t, "[INFO]  tick\n[INFO]  last message repeated 1 times\n", first.String())
+		assert.Equal(t, "[INFO]  tock\n", second.String())
+	})
+
+	t.Run("isn't fooled by a reused args slice", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:              &buf,
+			DisableTime:         true,
+			DeduplicateInterval: time.Hour,
+		})
+
+		args := []interface{}{"path", "/var"}
+		logger.Info("disk full", args...)
+
+		args[1] = "/tmp"
+		logger.Info("disk full", args...)
+
+		assert.Equal(t, "[INFO]  disk full: path=/var\n[INFO]  disk full: path=/tmp\n", buf.String())
+	})
+	t.Run("sees values changed behind a pointer", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:              &buf,
+			DisableTime:         true,
+			DeduplicateInterval: time.Hour,
+		})
+
+		type stats struct{ N int }
+
+		v := stats{N: 1}
+		logger.Info("stats", "s", &v)
+
+		v.N = 2
+		logger.Info("stats", "s", &v)
+
+		assert.Equal(t, "[INFO]  stats: s=\"&{1}\"\n[INFO]  stats: s=\"&{2}\"\n", buf.String())
+	})
+}
@@ -0,0 +1,553 @@
+package hclog
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ExcludeByQuery excludes log entries matching a query over their level,
+// logger name, message and key/value pairs. It is compiled once from a string
+// with CompileExcludeQuery, so that the query can come from configuration:
+//
+//	q, err := hclog.CompileExcludeQuery(`level<warn && module=="raft" && peer=~"10\.0\..*"`)
+//	...
+//	appLogger := hclog.New(&hclog.LoggerOptions{
+//		ExcludeNamed: q.ExcludeNamed,
+//	})
+//
+// A query is made of comparisons joined with && (or "and"), || (or "or"), and
+// negated with ! (or "not"), with parentheses for grouping. Each comparison
+// is a field, an operator and a value:
+//
+//	level    compared with ==, !=, <, <=, > and >= by severity, such as level>=warn
+//	module   the name of the logger
+//	message  the log message, also available as msg
+//	other    the value of the key/value pair with that key
+//
+// The operators for the other fields are == and != for equality, <, <=, > and
+// >= for ordering, and =~ and !~ for matching a regular expression anywhere in
+// the value. If the value in the query is a number, equality and ordering
+// compare numerically and never match values that aren't numbers. Values,
+// and keys, may be double quoted, and must be if they contain spaces or any
+// of the operator characters. Within quotes, only \" and \\ are escapes, so
+// that regular expressions can be written as they are. A field on its own
+// matches entries that have that key. A comparison on a key that the entry
+// doesn't have only matches with != and !~.
+//
+// Only ExcludeNamed knows the logger name and the pairs given to With, so the
+// Exclude method, for the Exclude option, sees an empty module and only the
+// pairs from the log call.
+type ExcludeByQuery struct {
+	query string
+	root  queryNode
+}
+
+// CompileExcludeQuery parses query and returns an ExcludeByQuery for it.
+func CompileExcludeQuery(query string) (*ExcludeByQuery, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{query: query, tokens: tokens}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != queryEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+
+	return &ExcludeByQuery{query: query, root: root}, nil
+}
+
+// MustCompileExcludeQuery is like CompileExcludeQuery but panics if the query
+// can't be parsed.
+func MustCompileExcludeQuery(query string) *ExcludeByQuery {
+	q, err := CompileExcludeQuery(query)
+	if err != nil {
+		panic(err)
+	}
+
+	return q
+}
+
+// Exclude returns true if the entry matches the query. It can be used as the
+// Exclude option, which is not given the logger name.
+func (q *ExcludeByQuery) Exclude(level Level, msg string, args ...interface{}) bool {
+	return q.ExcludeNamed("", level, msg, args...)
+}
+
+// ExcludeNamed returns true if the entry matches the query. It can be used as
+// the ExcludeNamed option.
+func (q *ExcludeByQuery) ExcludeNamed(name string, level Level, msg string, args ...interface{}) bool {
+	return q.root.eval(&queryEntry{
+		name:  name,
+		level: level,
+		msg:   msg,
+		args:  args,
+	})
+}
+
+// String returns the query the ExcludeByQuery was compiled from.
+func (q *ExcludeByQuery) String() string {
+	return q.query
+}
+
+// queryEntry is the log entry a query is evaluated against.
+type queryEntry struct {
+	name  string
+	level Level
+	msg   string
+	args  []interface{}
+}
+
+// lookup returns the value of field for the entry. For key/value pairs, the
+// last pair with the key wins, as pairs from the log call come after those
+// from With.
+func (e *queryEntry) lookup(field string) (interface{}, bool) {
+	switch field {
+	case "module":
+		return e.name, true
+	case "message", "msg":
+		return e.msg, true
+	}
+
+	for i := len(e.args)&^1 - 2; i >= 0; i -= 2 {
+		if renderKey(e.args[i]) == field {
+			return e.args[i+1], true
+		}
+	}
+
+	return nil, false
+}
+
+type queryNode interface {
+	eval(e *queryEntry) bool
+}
+
+type queryAnd struct {
+	left, right queryNode
+}
+
+func (n *queryAnd) eval(e *queryEntry) bool {
+	return n.left.eval(e) && n.right.eval(e)
+}
+
+type queryOr struct {
+	left, right queryNode
+}
+
+func (n *queryOr) eval(e *queryEntry) bool {
+	return n.left.eval(e) || n.right.eval(e)
+}
+
+type queryNot struct {
+	node queryNode
+}
+
+func (n *queryNot) eval(e *queryEntry) bool {
+	return !n.node.eval(e)
+}
+
+// queryLevel compares the level of the entry by severity.
+type queryLevel struct {
+	op       string
+	severity int32
+}
+
+func (n *queryLevel) eval(e *queryEntry) bool {
+	return compareQuery(n.op, int(levelSeverity(e.level))-int(n.severity))
+}
+
+// queryExists matches entries that have the field.
+type queryExists struct {
+	field string
+}
+
+func (n *queryExists) eval(e *queryEntry) bool {
+	_, ok := e.lookup(n.field)
+	return ok
+}
+
+// queryField compares the value of a field.
+type queryField struct {
+	field string
+	op    string
+	value string
+
+	// number is the value as a number, if it is one.
+	number   float64
+	isNumber bool
+
+	re *regexp.Regexp
+}
+
+func (n *queryField) eval(e *queryEntry) bool {
+	v, ok := e.lookup(n.field)
+	if !ok {
+		return n.op == "!=" || n.op == "!~"
+	}
+
+	switch n.op {
+	case "=~":
+		return n.re.MatchString(queryString(v))
+	case "!~":
+		return !n.re.MatchString(queryString(v))
+	}
+
+	if n.isNumber {
+		f, ok := queryNumber(v)
+		if !ok {
+			return n.op == "!="
+		}
+
+		switch {
+		case f < n.number:
+			return compareQuery(n.op, -1)
+		case f > n.number:
+			return compareQuery(n.op, 1)
+		default:
+			return compareQuery(n.op, 0)
+		}
+	}
+
+	return compareQuery(n.op, strings.Compare(queryString(v), n.value))
+}
+
+// compareQuery applies op to the result of comparing two values, which is
+// negative, zero or positive like strings.Compare.
+func compareQuery(op string, c int) bool {
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	default:
+		return false
+	}
+}
+
+// queryString returns v as a string, as it would be written in the plain
+// format but without quoting.
+func queryString(v interface{}) string {
+	switch st := v.(type) {
+	case string:
+		return st
+	case Quote:
+		return string(st)
+	case error:
+		return st.Error()
+	default:
+		val, _ := renderValue(v)
+		return val
+	}
+}
+
+// queryNumber returns v as a number, if it is one or is a string holding
+// one.
+func queryNumber(v interface{}) (float64, bool) {
+	if s, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, err == nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+type queryTokenKind int
+
+const (
+	queryEOF queryTokenKind = iota
+	queryWord
+	queryQuoted
+	queryOp
+	queryAndOp
+	queryOrOp
+	queryNotOp
+	queryLParen
+	queryRParen
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	pos  int
+}
+
+// queryOps are the comparison operators, with the two character ones first
+// so that they are matched in preference to their prefixes.
+var queryOps = []string{"==", "!=", "<=", ">=", "=~", "!~", "<", ">"}
+
+// lexQuery splits query into tokens.
+func lexQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+
+	i := 0
+	for i < len(query) {
+		c := query[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '(':
+			tokens = append(tokens, queryToken{kind: queryLParen, text: "(", pos: i})
+			i++
+			continue
+		case c == ')':
+			tokens = append(tokens, queryToken{kind: queryRParen, text: ")", pos: i})
+			i++
+			continue
+		case strings.HasPrefix(query[i:], "&&"):
+			tokens = append(tokens, queryToken{kind: queryAndOp, text: "&&", pos: i})
+			i += 2
+			continue
+		case strings.HasPrefix(query[i:], "||"):
+			tokens = append(tokens, queryToken{kind: queryOrOp, text: "||", pos: i})
+			i += 2
+			continue
+		case c == '"':
+			var (
+				b   strings.Builder
+				end = i + 1
+			)
+			for ; end < len(query) && query[end] != '"'; end++ {
+				if query[end] == '\\' && end+1 < len(query) && (query[end+1] == '"' || query[end+1] == '\\') {
+					end++
+				}
+				b.WriteByte(query[end])
+			}
+			if end >= len(query) {
+				return nil, fmt.Errorf("invalid exclude query: unterminated string at offset %d", i)
+			}
+
+			tokens = append(tokens, queryToken{kind: queryQuoted, text: b.String(), pos: i})
+			i = end + 1
+			continue
+		}
+
+		op := ""
+		for _, o := range queryOps {
+			if strings.HasPrefix(query[i:], o) {
+				op = o
+				break
+			}
+		}
+		if op != "" {
+			tokens = append(tokens, queryToken{kind: queryOp, text: op, pos: i})
+			i += len(op)
+			continue
+		}
+
+		if c == '!' {
+			tokens = append(tokens, queryToken{kind: queryNotOp, text: "!", pos: i})
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(query) && !strings.ContainsRune(" \t\n\r()!&|=<>~\"", rune(query[end])) {
+			end++
+		}
+		if end == i {
+			return nil, fmt.Errorf("invalid exclude query: unexpected %q at offset %d", c, i)
+		}
+
+		word := query[i:end]
+		tok := queryToken{kind: queryWord, text: word, pos: i}
+
+		switch strings.ToLower(word) {
+		case "and":
+			tok.kind = queryAndOp
+		case "or":
+			tok.kind = queryOrOp
+		case "not":
+			tok.kind = queryNotOp
+		}
+
+		tokens = append(tokens, tok)
+		i = end
+	}
+
+	return append(tokens, queryToken{kind: queryEOF, pos: len(query)}), nil
+}
+
+// queryParser is a recursive descent parser for queries:
+//
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" or ")" | comparison
+//	comparison = field [ op value ]
+type queryParser struct {
+	query  string
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != queryEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *queryParser) errorf(tok queryToken, format string, args ...interface{}) error {
+	return fmt.Errorf("invalid exclude query: %s at offset %d", fmt.Sprintf(format, args...), tok.pos)
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == queryOrOp {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &queryOr{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == queryAndOp {
+		p.next()
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &queryAnd{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case queryNotOp:
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &queryNot{node: node}, nil
+	case queryLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if end := p.next(); end.kind != queryRParen {
+			return nil, p.errorf(end, "expected \")\"")
+		}
+
+		return node, nil
+	case queryWord, queryQuoted:
+		return p.parseComparison(tok)
+	case queryEOF:
+		return nil, p.errorf(tok, "unexpected end of query")
+	default:
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+}
+
+func (p *queryParser) parseComparison(field queryToken) (queryNode, error) {
+	if p.peek().kind != queryOp {
+		if field.text == "level" {
+			return nil, p.errorf(field, "level must be compared with a level")
+		}
+
+		return &queryExists{field: field.text}, nil
+	}
+
+	op := p.next()
+
+	val := p.next()
+	if val.kind != queryWord && val.kind != queryQuoted {
+		return nil, p.errorf(val, "expected a value after %q", op.text)
+	}
+
+	if field.kind == queryWord && field.text == "level" {
+		switch op.text {
+		case "=~", "!~":
+			return nil, p.errorf(op, "level can't be compared with %q", op.text)
+		}
+
+		level := LevelFromString(val.text)
+		if level == NoLevel {
+			return nil, p.errorf(val, "unknown level %q", val.text)
+		}
+
+		return &queryLevel{op: op.text, severity: levelSeverity(level)}, nil
+	}
+
+	n := &queryField{
+		field: field.text,
+		op:    op.text,
+		value: val.text,
+	}
+
+	switch op.text {
+	case "=~", "!~":
+		re, err := regexp.Compile(val.text)
+		if err != nil {
+			return nil, p.errorf(val, "invalid regexp: %s", err)
+		}
+		n.re = re
+	default:
+		// Only bare values are treated as numbers, so that quoting a
+		// value always compares it as a string.
+		if val.kind == queryWord {
+			if f, err := strconv.ParseFloat(val.text, 64); err == nil {
+				n.number, n.isNumber = f, true
+			}
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,151 @@
+package hclog
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcludeByQuery(t *testing.T) {
+	cases := []struct {
+		query   string
+		name    string
+		level   Level
+		msg     string
+		args    []interface{}
+		exclude bool
+	}{
+		{query: `level<warn`, level: Info, exclude: true},
+		{query: `level<warn`, level: Warn, exclude: false},
+		{query: `level >= error`, level: Error, exclude: true},
+		{query: `level==debug`, level: Debug, exclude: true},
+		{query: `level!=debug`, level: Debug, exclude: false},
+		{query: `module=="raft"`, name: "raft", exclude: true},
+		{query: `module=="raft"`, name: "raft.snapshot", exclude: false},
+		{query: `module=~"^raft\."`, name: "raft.snapshot", exclude: true},
+		{query: `message=~"^heartbeat"`, msg: "heartbeat sent", exclude: true},
+		{query: `msg == "heartbeat sent"`, msg: "heartbeat sent", exclude: true},
+		{query: `peer=~"10\.0\..*"`, args: []interface{}{"peer", "10.0.0.1"}, exclude: true},
+		{query: `peer=~"10\.0\..*"`, args: []interface{}{"peer", "192.168.0.1"}, exclude: false},
+		{query: `peer!~"10\.0\..*"`, args: []interface{}{"peer", "192.168.0.1"}, exclude: true},
+		{query: `peer==10.0.0.1`, args: []interface{}{"peer", "10.0.0.1"}, exclude: true},
+		{query: `peer`, args: []interface{}{"peer", "10.0.0.1"}, exclude: true},
+		{query: `peer`, args: []interface{}{"other", "10.0.0.1"}, exclude: false},
+		{query: `peer=="x"`, exclude: false},
+		{query: `peer!="x"`, exclude: true},
+		{query: `attempts>3`, args: []interface{}{"attempts", 4}, exclude: true},
+		{query: `attempts>3`, args: []interface{}{"attempts", uint8(3)}, exclude: false},
+		{query: `attempts>=3`, args: []interface{}{"attempts", "3"}, exclude: true},
+		{query: `attempts>3`, args: []interface{}{"attempts", "many"}, exclude: false},
+		{query: `latency<0.5`, args: []interface{}{"latency", 0.25}, exclude: true},
+		{query: `attempts=="3"`, args: []interface{}{"attempts", 3}, exclude: true},
+		{query: `version<"1.10"`, args: []interface{}{"version", "1.9"}, exclude: false},
+		{query: `error=~"timeout"`, args: []interface{}{"error", errors.New("i/o timeout")}, exclude: true},
+		{query: `path=="/health"`, args: []interface{}{"path", "/", "path", "/health"}, exclude: true},
+		{query: `level<warn && module=="raft" && peer=~"10\.0\..*"`, name: "raft", level: Debug, args: []interface{}{"peer", "10.0.0.7"}, exclude: true},
+		{query: `level<warn && module=="raft" && peer=~"10\.0\..*"`, name: "raft", level: Warn, args: []interface{}{"peer", "10.0.0.7"}, exclude: false},
+		{query: `module=="raft" || module=="http"`, name: "http", exclude: true},
+		{query: `module=="raft" or module=="http"`, name: "grpc", exclude: false},
+		{query: `!(module=="raft")`, name: "http", exclude: true},
+		{query: `not module=="raft" and level<warn`, name: "http", level: Info, exclude: true},
+		{query: `level<warn && (module=="raft" || module=="http")`, name: "http", level: Info, exclude: true},
+		{query: `level<warn && module=="raft" || module=="http"`, name: "http", level: Error, exclude: true},
+		{query: `"my key"=="a b"`, args: []interface{}{"my key", "a b"}, exclude: true},
+		{query: `msg=="say \"hi\" \\o/"`, msg: `say "hi" \o/`, exclude: true},
+	}
+
+	for _, c := range cases {
+		q, err := CompileExcludeQuery(c.query)
+		require.NoError(t, err, c.query)
+
+		assert.Equal(t, c.exclude, q.ExcludeNamed(c.name, c.level, c.msg, c.args...), "%s with %v", c.query, c.args)
+	}
+}
+
+func TestExcludeByQuery_Errors(t *testing.T) {
+	for _, query := range []string{
+		``,
+		`level`,
+		`level<loud`,
+		`level=~"warn"`,
+		`peer==`,
+		`peer=="unterminated`,
+		`peer=~"("`,
+		`(peer`,
+		`peer)`,
+		`peer = "x"`,
+		`&& peer`,
+		`peer || `,
+	} {
+		_, err := CompileExcludeQuery(query)
+		assert.Error(t, err, query)
+	}
+
+	assert.Panics(t, func() { MustCompileExcludeQuery(`level<`) })
+}
+
+func TestExcludeByQuery_Logger(t *testing.T) {
+	t.Run("works with the Exclude option", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		q := MustCompileExcludeQuery(`level<warn && peer=~"^10\.0\."`)
+
+		logger := New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+			Exclude:     q.Exclude,
+		})
+
+		logger.Info("heartbeat", "peer", "10.0.0.1")
+		logger.Warn("heartbeat failed", "peer", "10.0.0.1")
+		logger.Info("heartbeat", "peer", "192.168.0.1")
+
+		assert.Equal(t, "[WARN]  heartbeat failed: peer=10.0.0.1\n[INFO]  heartbeat: peer=192.168.0.1\n", buf.String())
+	})
+
+	t.Run("sees the name and implied args with ExcludeNamed", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		q := MustCompileExcludeQuery(`module=="app.raft" && peer=~"^10\.0\."`)
+
+		logger := New(&LoggerOptions{
+			Name:         "app",
+			Output:       &buf,
+			DisableTime:  true,
+			ExcludeNamed: q.ExcludeNamed,
+		})
+
+		raft := logger.Named("raft")
+		raft.With("peer", "10.0.0.1").Info("heartbeat")
+		raft.With("peer", "10.0.0.1").Info("heartbeat", "peer", "192.168.0.1")
+		logger.With("peer", "10.0.0.1").Info("heartbeat")
+
+		assert.Equal(t, "[INFO]  app.raft: heartbeat: peer=10.0.0.1 peer=192.168.0.1\n[INFO]  app: heartbeat: peer=10.0.0.1\n", buf.String())
+	})
+
+	t.Run("is used by intercept loggers", func(t *testing.T) {
+		var buf, sbuf bytes.Buffer
+
+		q := MustCompileExcludeQuery(`level<error`)
+
+		logger := NewInterceptLogger(&LoggerOptions{
+			Output:       &buf,
+			DisableTime:  true,
+			ExcludeNamed: q.ExcludeNamed,
+		})
+		logger.RegisterSink(NewSinkAdapter(&LoggerOptions{
+			Output:      &sbuf,
+			DisableTime: true,
+		}))
+
+		logger.Info("started", "after", time.Second)
+		logger.Error("failed")
+
+		assert.Equal(t, "[ERROR] failed\n", buf.String())
+		assert.Equal(t, "[INFO]  started: after=1s\n[ERROR] failed\n", sbuf.String())
+	})
+}
@@ -0,0 +1,385 @@
+package hclog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errGELFWriterClosed is returned when writing to a closed GELFWriter
+var errGELFWriterClosed = errors.New("gelf writer is closed")
+
+// errGELFTooLarge is returned when a message needs more than the maximum
+// number of chunks to be sent over UDP
+var errGELFTooLarge = errors.New("gelf message too large to send over udp")
+
+const (
+	// gelfMaxChunks is the most chunks a GELF message may be split into.
+	gelfMaxChunks = 128
+
+	// gelfChunkHeaderSize is the size of the magic bytes, message id,
+	// sequence number and sequence count at the start of each chunk.
+	gelfChunkHeaderSize = 12
+)
+
+// GELFEncoder is an Encoder that writes entries as GELF 1.1 messages, for
+// sending to Graylog.
+//
+// The message is sent as short_message and the level as the syslog severity.
+// The logger name and the caller, with IncludeLocation, are sent as the
+// _module and _caller fields. The key/value pairs from the log call are
+// converted in the same way as for JSONFormat and sent as additional fields,
+// prefixed with an underscore. Values spanning multiple lines and
+// stacktraces are sent in full_message instead.
+//
+// It's meant to be used together with a GELFWriter as the Output, which
+// sends each entry as a single message.
+type GELFEncoder struct {
+	// The host sent with each message. Defaults to os.Hostname, which is
+	// looked up once, with the first message.
+	Host string
+
+	hostOnce sync.Once
+	host     string
+}
+
+var _ Encoder = &GELFEncoder{}
+
+// Encode writes the entry to buf as a GELF message, followed by a newline.
+func (e *GELFEncoder) Encode(buf *bytes.Buffer, t time.Time, name string, level Level, msg string, caller *runtime.Frame, implied, args []interface{}) error {
+	vals := e.gelfEntry(t, name, level, msg, caller)
+	args = append(implied, args...)
+
+	var (
+		full       []string
+		stacktrace CapturedStacktrace
+	)
+
+	if len(args)%2 != 0 {
+		if cs, ok := args[len(args)-1].(CapturedStacktrace); ok {
+			args = args[:len(args)-1]
+			stacktrace = cs
+		} else {
+			extra := args[len(args)-1]
+			args = append(args[:len(args)-1], MissingKey, extra)
+		}
+	}
+
+	for i := 0; i < len(args); i = i + 2 {
+		key := renderKey(args[i])
+		val := jsonValue(args[i+1])
+
+		switch sv := val.(type) {
+		case CapturedStacktrace:
+			stacktrace = sv
+			continue
+		case string:
+			if strings.Contains(sv, "\n") {
+				full = append(full, key+":\n"+sv)
+				continue
+			}
+		}
+
+		vals[gelfFieldName(key)] = val
+	}
+
+	if stacktrace != "" {
+		full = append(full, "stacktrace:\n"+strings.TrimRight(string(stacktrace), "\n"))
+	}
+
+	if len(full) > 0 {
+		vals["full_message"] = msg + "\n\n" + strings.Join(full, "\n\n")
+	}
+
+	err := json.NewEncoder(buf).Encode(vals)
+	if err != nil {
+		if _, ok := err.(*json.UnsupportedTypeError); ok {
+			plainVal := e.gelfEntry(t, name, level, msg, caller)
+			plainVal["_warn"] = errJsonUnsupportedTypeMsg
+
+			return json.NewEncoder(buf).Encode(plainVal)
+		}
+	}
+
+	return err
+}
+
+func (e *GELFEncoder) gelfEntry(t time.Time, name string, level Level, msg string, caller *runtime.Frame) map[string]interface{} {
+	e.hostOnce.Do(func() {
+		e.host = e.Host
+		if e.host == "" {
+			e.host, _ = os.Hostname()
+		}
+	})
+
+	// short_message is required to be non-empty.
+	if msg == "" {
+		msg = "-"
+	}
+
+	vals := map[string]interface{}{
+		"version":       "1.1",
+		"host":          e.host,
+		"short_message": msg,
+		"timestamp":     json.Number(strconv.FormatFloat(float64(t.UnixNano())/float64(time.Second), 'f', 3, 64)),
+		"level":         syslogSeverity(level),
+	}
+
+	if name != "" {
+		vals["_module"] = name
+	}
+
+	if caller != nil {
+		vals["_caller"] = fmt.Sprintf("%s:%d", caller.File, caller.Line)
+	}
+
+	return vals
+}
+
+// gelfFieldName returns the name of the additional field for key. The name
+// may only contain letters, digits, underscores, dashes and dots, and _id is
+// reserved.
+func gelfFieldName(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '_', c == '-', c == '.':
+		default:
+			b[i] = '_'
+		}
+	}
+
+	name := "_" + string(b)
+	if name == "_id" {
+		name = "__id"
+	}
+
+	return name
+}
+
+// GELFOptions can be used to configure a GELFWriter.
+type GELFOptions struct {
+	// The network to send messages over, "udp" or "tcp". Defaults to "udp".
+	Network string
+
+	// The address of the Graylog input.
+	Address string
+
+	// The largest UDP datagram to send. Larger messages are split into up to
+	// 128 chunks. Defaults to 1420, which fits in a typical ethernet frame.
+	ChunkSize int
+
+	// Compress messages sent over UDP with gzip.
+	Compress bool
+
+	// The timeout for connecting to the server. Defaults to 10 seconds.
+	DialTimeout time.Duration
+}
+
+// GELFWriter is an io.Writer that sends each write as a GELF message to
+// Graylog. It's meant to be used as the Output for a Logger that uses a
+// GELFEncoder:
+//
+//	w, err := hclog.NewGELFWriter(&hclog.GELFOptions{Address: "graylog:12201"})
+//	...
+//	logger := hclog.New(&hclog.LoggerOptions{
+//		Output:  w,
+//		Encoder: &hclog.GELFEncoder{},
+//	})
+//
+// Over UDP, messages larger than ChunkSize are chunked. Over TCP, messages
+// are delimited with a null byte, and the connection is reopened and the
+// message sent again once if it can't be sent.
+type GELFWriter struct {
+	network   string
+	address   string
+	chunkSize int
+	compress  bool
+	timeout   time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	buf    bytes.Buffer
+	closed bool
+}
+
+// NewGELFWriter returns a GELFWriter connected to the configured address.
+func NewGELFWriter(opts *GELFOptions) (*GELFWriter, error) {
+	if opts == nil || opts.Address == "" {
+		return nil, errors.New("no address given for gelf output")
+	}
+
+	w := &GELFWriter{
+		network:   opts.Network,
+		address:   opts.Address,
+		chunkSize: opts.ChunkSize,
+		compress:  opts.Compress,
+		timeout:   opts.DialTimeout,
+	}
+
+	if w.network == "" {
+		w.network = "udp"
+	}
+	if w.chunkSize <= 0 {
+		w.chunkSize = 1420
+	}
+	if w.chunkSize <= gelfChunkHeaderSize {
+		return nil, fmt.Errorf("gelf chunk size too small: %d", w.chunkSize)
+	}
+	if w.timeout <= 0 {
+		w.timeout = 10 * time.Second
+	}
+
+	switch w.network {
+	case "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("unsupported gelf network: %s", w.network)
+	}
+
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write sends p as a single GELF message.
+func (w *GELFWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errGELFWriterClosed
+	}
+
+	msg := bytes.TrimRight(p, "\n")
+
+	var err error
+	if w.network == "udp" {
+		err = w.writeUDP(msg)
+	} else {
+		err = w.writeTCP(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (w *GELFWriter) writeUDP(msg []byte) error {
+	if w.compress {
+		w.buf.Reset()
+
+		gz := gzip.NewWriter(&w.buf)
+		gz.Write(msg)
+		if err := gz.Close(); err != nil {
+			return err
+		}
+
+		msg = w.buf.Bytes()
+	}
+
+	if len(msg) <= w.chunkSize {
+		_, err := w.conn.Write(msg)
+		return err
+	}
+
+	size := w.chunkSize - gelfChunkHeaderSize
+	count := (len(msg) + size - 1) / size
+	if count > gelfMaxChunks {
+		return errGELFTooLarge
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, 0, w.chunkSize)
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * size
+		if end > len(msg) {
+			end = len(msg)
+		}
+
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunk = append(chunk, msg[seq*size:end]...)
+
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *GELFWriter) writeTCP(msg []byte) error {
+	w.buf.Reset()
+	w.buf.Write(msg)
+	w.buf.WriteByte(0)
+
+	if w.conn != nil {
+		if _, err := w.conn.Write(w.buf.Bytes()); err == nil {
+			return nil
+		}
+	}
+
+	if err := w.connect(); err != nil {
+		return err
+	}
+
+	if _, err := w.conn.Write(w.buf.Bytes()); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+// Close closes the connection to Graylog. Writes after Close return an error.
+func (w *GELFWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// connect replaces the current connection, if any, with a new one. The lock
+// must be held.
+func (w *GELFWriter) connect() error {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	conn, err := net.DialTimeout(w.network, w.address, w.timeout)
+	if err != nil {
+		return err
+	}
+
+	w.conn = conn
+	return nil
+}
@@ -0,0 +1,274 @@
+package hclog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGELFEncoder(t *testing.T) {
+	testTime := time.Date(2022, 5, 10, 12, 30, 15, 123456789, time.UTC)
+
+	newLogger := func(buf *bytes.Buffer) Logger {
+		return New(&LoggerOptions{
+			Name:    "test",
+			Output:  buf,
+			Encoder: &GELFEncoder{Host: "myhost"},
+			TimeFn:  func() time.Time { return testTime },
+		})
+	}
+
+	decode := func(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &raw))
+		return raw
+	}
+
+	t.Run("writes a gelf message", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := newLogger(&buf)
+		logger.Warn("this is test", "who", "programmer", "why", Fmt("testing %d", 42))
+
+		assert.Equal(t, map[string]interface{}{
+			"version":       "1.1",
+			"host":          "myhost",
+			"short_message": "this is test",
+			"timestamp":     1652185815.123,
+			"level":         float64(4),
+			"_module":       "test",
+			"_who":          "programmer",
+			"_why":          "testing 42",
+		}, decode(t, &buf))
+	})
+
+	t.Run("sends multi-line values and stacktraces in full_message", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := newLogger(&buf)
+		logger.Error("this is test", "lines", "one\ntwo", "n", 1, Stacktrace())
+
+		raw := decode(t, &buf)
+		assert.Equal(t, float64(3), raw["level"])
+		assert.Equal(t, float64(1), raw["_n"])
+		assert.NotContains(t, raw, "_lines")
+
+		full := raw["full_message"].(string)
+		assert.True(t, strings.HasPrefix(full, "this is test\n\nlines:\none\ntwo\n\nstacktrace:\n"), full)
+		assert.Contains(t, full, "TestGELFEncoder")
+	})
+
+	t.Run("converts errors like json", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := newLogger(&buf)
+		logger.Info("this is test", "err", errors.New("this is an error"), "custom", customErrJSON{"foo"})
+
+		raw := decode(t, &buf)
+		assert.Equal(t, "this is an error", raw["_err"])
+		assert.Equal(t, "json-marshaler: foo", raw["_custom"])
+	})
+
+	t.Run("handles unsupported types", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := newLogger(&buf)
+		logger.Info("this is test", "fn", func() {})
+
+		raw := decode(t, &buf)
+		assert.Equal(t, "this is test", raw["short_message"])
+		assert.Equal(t, errJsonUnsupportedTypeMsg, raw["_warn"])
+		assert.NotContains(t, raw, "_fn")
+	})
+
+	t.Run("defaults to the hostname", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		hostname, err := os.Hostname()
+		require.NoError(t, err)
+
+		enc := &GELFEncoder{}
+		logger := New(&LoggerOptions{Output: &buf, Encoder: enc})
+
+		logger.Info("first")
+		assert.Equal(t, hostname, decode(t, &buf)["host"])
+
+		// The hostname is only looked up once.
+		enc.host = "cached"
+		buf.Reset()
+		logger.Info("second")
+		assert.Equal(t, "cached", decode(t, &buf)["host"])
+	})
+
+	t.Run("sanitizes field names", func(t *testing.T) {
+		assert.Equal(t, "_request.id", gelfFieldName("request.id"))
+		assert.Equal(t, "_a_b", gelfFieldName("a b"))
+		assert.Equal(t, "__id", gelfFieldName("id"))
+	})
+}
+
+func TestGELFWriter(t *testing.T) {
+	t.Run("sends messages over udp", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		w, err := NewGELFWriter(&GELFOptions{
+			Address: conn.LocalAddr().String(),
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		_, err = w.Write([]byte("{\"short_message\":\"hello\"}\n"))
+		require.NoError(t, err)
+
+		buf := make([]byte, 2048)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"short_message":"hello"}`, string(buf[:n]))
+	})
+
+	t.Run("chunks large messages over udp", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		w, err := NewGELFWriter(&GELFOptions{
+			Address:   conn.LocalAddr().String(),
+			ChunkSize: 100,
+			Compress:  true,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		// Random-ish data, so that it doesn't compress down to a single chunk.
+		var sb strings.Builder
+		for i := 0; i < 200; i++ {
+			sb.WriteString(time.Duration(i * 7919).String())
+		}
+		msg := `{"short_message":"` + sb.String() + `"}`
+
+		_, err = w.Write([]byte(msg + "\n"))
+		require.NoError(t, err)
+
+		var (
+			id     []byte
+			count  int
+			chunks = map[int][]byte{}
+		)
+
+		buf := make([]byte, 2048)
+		for count == 0 || len(chunks) < count {
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			n, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			require.True(t, n <= 100)
+
+			chunk := append([]byte(nil), buf[:n]...)
+			require.Equal(t, []byte{0x1e, 0x0f}, chunk[:2])
+
+			if id == nil {
+				id = chunk[2:10]
+			}
+			assert.Equal(t, id, chunk[2:10])
+
+			count = int(chunk[11])
+			chunks[int(chunk[10])] = chunk[12:]
+		}
+		assert.True(t, count > 1)
+
+		var compressed []byte
+		for seq := 0; seq < count; seq++ {
+			compressed = append(compressed, chunks[seq]...)
+		}
+
+		gz, err := gzip.NewReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, msg, string(data))
+	})
+
+	t.Run("rejects messages needing too many chunks", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		w, err := NewGELFWriter(&GELFOptions{
+			Address:   conn.LocalAddr().String(),
+			ChunkSize: 20,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		_, err = w.Write(bytes.Repeat([]byte("x"), 8*gelfMaxChunks+1))
+		assert.Equal(t, errGELFTooLarge, err)
+	})
+
+	t.Run("sends null delimited messages over tcp", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		w, err := NewGELFWriter(&GELFOptions{
+			Network: "tcp",
+			Address: ln.Addr().String(),
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		conn, err := ln.Accept()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		logger := New(&LoggerOptions{
+			Name:    "test",
+			Output:  w,
+			Encoder: &GELFEncoder{Host: "myhost"},
+		})
+
+		logger.Info("first")
+		logger.Info("second")
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		r := bufio.NewReader(conn)
+
+		for _, msg := range []string{"first", "second"} {
+			line, err := r.ReadBytes(0)
+			require.NoError(t, err)
+
+			var raw map[string]interface{}
+			require.NoError(t, json.Unmarshal(line[:len(line)-1], &raw))
+			assert.Equal(t, msg, raw["short_message"])
+		}
+	})
+
+	t.Run("errors after close", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		w, err := NewGELFWriter(&GELFOptions{
+			Address: conn.LocalAddr().String(),
+		})
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		_, err = w.Write([]byte("{}\n"))
+		assert.Error(t, err)
+	})
+}
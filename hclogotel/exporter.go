@@ -0,0 +1,623 @@
+package hclogotel
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TerminusDeus/go-hclog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// errExporterClosed is returned when flushing a closed LogExporter
+var errExporterClosed = errors.New("log exporter is closed")
+
+// DefaultEndpoint is the OTLP/HTTP logs endpoint of a collector running
+// locally.
+const DefaultEndpoint = "http://localhost:4318/v1/logs"
+
+// DefaultScope is the instrumentation scope of entries from loggers without a
+// name.
+const DefaultScope = "github.com/TerminusDeus/go-hclog"
+
+// ExporterOptions can be used to configure a LogExporter.
+type ExporterOptions struct {
+	// The URL to send logs to. Defaults to DefaultEndpoint.
+	Endpoint string
+
+	// Headers added to every request, such as for authentication.
+	Headers map[string]string
+
+	// The client used to send requests. Defaults to a client with a 10
+	// second timeout.
+	Client *http.Client
+
+	// The name of the service, sent as the service.name resource attribute
+	// unless Resource has one. Defaults to the name of the program.
+	ServiceName string
+
+	// Attributes describing the resource the logs come from.
+	Resource []attribute.KeyValue
+
+	// The most records sent in one request. Defaults to 512.
+	BatchSize int
+
+	// How long records can wait before they are sent, if the batch doesn't
+	// fill up first. Defaults to one second.
+	FlushInterval time.Duration
+
+	// The number of records that can be waiting to be sent. Records are
+	// dropped once it's full. Defaults to 2048.
+	QueueSize int
+
+	// The number of times a request is retried when the collector is
+	// unavailable or asks for the request to be retried. Defaults to 3.
+	MaxRetries int
+
+	// Called with any error sending a batch. The batch is dropped.
+	OnError func(error)
+
+	// The least severe level exported, compared by severity so that levels
+	// added with hclog.RegisterLevel are exported too. Defaults to
+	// hclog.Info.
+	Level hclog.Level
+}
+
+// LogExporter is an hclog.SinkAdapter that converts entries into records of
+// the OpenTelemetry logs data model and sends them in batches to a collector
+// with OTLP/HTTP, encoded as JSON. Register it on an hclog.InterceptLogger:
+//
+//	exp, err := hclogotel.NewLogExporter(&hclogotel.ExporterOptions{ServiceName: "my-app"})
+//	...
+//	logger.RegisterSink(exp)
+//	defer exp.Close()
+//
+// The level is sent as the severity number and text, the logger name as the
+// instrumentation scope, and the key/value pairs as attributes, keeping the
+// type of strings, booleans, numbers and slices of them. The trace_id,
+// span_id and trace_flags fields added by this package are sent as the trace
+// context of the record instead.
+//
+// Records are queued and sent from a background goroutine, so Close must be
+// called on shutdown so that none are lost.
+type LogExporter struct {
+	// dropped is accessed atomically, so is kept first to ensure 64 bit
+	// alignment on 32 bit platforms.
+	dropped uint64
+
+	endpoint   string
+	headers    map[string]string
+	client     *http.Client
+	resource   []otlpKeyValue
+	batchSize  int
+	interval   time.Duration
+	maxRetries int
+	onError    func(error)
+	minLevel   int32
+
+	queue   chan otlpLogRecord
+	flushes chan chan error
+	done    chan struct{}
+
+	// closeMu is held for reading while enqueuing, so that Close can safely
+	// close the queue.
+	closeMu   sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var _ hclog.SinkAdapter = &LogExporter{}
+
+// NewLogExporter returns a LogExporter and starts the goroutine sending its
+// batches.
+func NewLogExporter(opts *ExporterOptions) (*LogExporter, error) {
+	if opts == nil {
+		opts = &ExporterOptions{}
+	}
+
+	e := &LogExporter{
+		endpoint:   opts.Endpoint,
+		headers:    opts.Headers,
+		client:     opts.Client,
+		batchSize:  opts.BatchSize,
+		interval:   opts.FlushInterval,
+		maxRetries: opts.MaxRetries,
+		onError:    opts.OnError,
+		flushes:    make(chan chan error),
+		done:       make(chan struct{}),
+	}
+
+	if e.endpoint == "" {
+		e.endpoint = DefaultEndpoint
+	}
+	if e.client == nil {
+		e.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if e.batchSize <= 0 {
+		e.batchSize = 512
+	}
+	if e.interval <= 0 {
+		e.interval = time.Second
+	}
+	if e.maxRetries <= 0 {
+		e.maxRetries = 3
+	}
+
+	level := opts.Level
+	if level == hclog.NoLevel {
+		level = hclog.Info
+	}
+	e.minLevel = level.Severity()
+
+	size := opts.QueueSize
+	if size <= 0 {
+		size = 2048
+	}
+	e.queue = make(chan otlpLogRecord, size)
+
+	if _, err := http.NewRequest(http.MethodPost, e.endpoint, nil); err != nil {
+		return nil, fmt.Errorf("invalid otlp endpoint: %w", err)
+	}
+
+	hasServiceName := false
+	for _, kv := range opts.Resource {
+		if kv.Key == "service.name" {
+			hasServiceName = true
+		}
+		e.resource = append(e.resource, otlpKeyValue{Key: string(kv.Key), Value: attributeValue(kv.Value)})
+	}
+	if !hasServiceName {
+		name := opts.ServiceName
+		if name == "" {
+			name = filepath.Base(os.Args[0])
+		}
+		e.resource = append(e.resource, otlpKeyValue{Key: "service.name", Value: stringValue(name)})
+	}
+
+	go e.run()
+
+	return e, nil
+}
+
+// Accept converts the entry into a record and queues it to be sent. The
+// record is dropped if the queue is full or the exporter has been closed.
+// Entries below the exporter's level are ignored.
+func (e *LogExporter) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	if level == hclog.NoLevel || level == hclog.Off || level.Severity() < e.minLevel {
+		return
+	}
+
+	rec := newLogRecord(time.Now(), name, level, msg, args)
+
+	e.closeMu.RLock()
+	defer e.closeMu.RUnlock()
+
+	if e.closed {
+		atomic.AddUint64(&e.dropped, 1)
+		return
+	}
+
+	select {
+	case e.queue <- rec:
+	default:
+		atomic.AddUint64(&e.dropped, 1)
+	}
+}
+
+// Dropped returns the number of records dropped because the queue was full
+// or the exporter had been closed.
+func (e *LogExporter) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
+
+// Flush sends the records queued so far, returning the error from sending
+// them, if any.
+func (e *LogExporter) Flush() error {
+	e.closeMu.RLock()
+	if e.closed {
+		e.closeMu.RUnlock()
+		return errExporterClosed
+	}
+
+	ch := make(chan error, 1)
+	e.flushes <- ch
+	e.closeMu.RUnlock()
+
+	return <-ch
+}
+
+// Close sends everything still queued and stops the background goroutine,
+// returning the error from sending the last batches, if any.
+func (e *LogExporter) Close() error {
+	e.closeOnce.Do(func() {
+		e.closeMu.Lock()
+		e.closed = true
+		close(e.queue)
+		e.closeMu.Unlock()
+
+		<-e.done
+	})
+
+	return e.closeErr
+}
+
+// run sends batches until the queue is closed.
+func (e *LogExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	batch := make([]otlpLogRecord, 0, e.batchSize)
+
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := e.export(batch)
+		if err != nil && e.onError != nil {
+			e.onError(err)
+		}
+
+		batch = batch[:0]
+		return err
+	}
+
+	for {
+		select {
+		case rec, ok := <-e.queue:
+			if !ok {
+				e.closeErr = send()
+				return
+			}
+
+			batch = append(batch, rec)
+			if len(batch) >= e.batchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ch := <-e.flushes:
+			var err error
+
+		drain:
+			for {
+				select {
+				case rec := <-e.queue:
+					batch = append(batch, rec)
+					if len(batch) >= e.batchSize {
+						if serr := send(); err == nil {
+							err = serr
+						}
+					}
+				default:
+					break drain
+				}
+			}
+
+			if serr := send(); err == nil {
+				err = serr
+			}
+
+			ch <- err
+		}
+	}
+}
+
+// export sends a batch of records, retrying if the collector asks for it.
+func (e *LogExporter) export(batch []otlpLogRecord) error {
+	body, err := json.Marshal(e.request(batch))
+	if err != nil {
+		return err
+	}
+
+	backoff := 100 * time.Millisecond
+
+	for attempt := 0; ; attempt++ {
+		retry, err := e.post(body)
+		if err == nil || !retry || attempt == e.maxRetries {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends one request, returning whether a failure can be retried.
+func (e *LogExporter) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("otlp export failed: %s", resp.Status)
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true, err
+	default:
+		return false, err
+	}
+}
+
+// request groups the batch by instrumentation scope, keeping the order in
+// which each scope first appears.
+func (e *LogExporter) request(batch []otlpLogRecord) *otlpRequest {
+	var (
+		scopes []otlpScopeLogs
+		index  = map[string]int{}
+	)
+
+	for _, rec := range batch {
+		i, ok := index[rec.scope]
+		if !ok {
+			i = len(scopes)
+			index[rec.scope] = i
+			scopes = append(scopes, otlpScopeLogs{Scope: otlpScope{Name: rec.scope}})
+		}
+
+		scopes[i].LogRecords = append(scopes[i].LogRecords, rec)
+	}
+
+	return &otlpRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource:  otlpResource{Attributes: e.resource},
+			ScopeLogs: scopes,
+		}},
+	}
+}
+
+// SeverityNumber returns the severity number of the OpenTelemetry logs data
+// model for level. Trace through Error are mapped to TRACE through ERROR,
+// Panic to FATAL and Fatal to FATAL2 above it.
+//
+// Levels added with hclog.RegisterLevel are mapped by their severity, so a
+// level between Info and Warn gets one of INFO2 to INFO4, and a level above
+// Error one of FATAL to FATAL4. Off and unknown levels are UNSPECIFIED.
+func SeverityNumber(level hclog.Level) int {
+	if level == hclog.NoLevel || level == hclog.Off || level.String() == "unknown" {
+		return 0
+	}
+
+	severity := int(level.Severity())
+
+	switch {
+	case severity < 100:
+		return 1
+	case severity < 600:
+		// Trace through Error, 100 apart, each cover four numbers.
+		return 1 + (severity/100-1)*4 + (severity%100)*4/100
+	default:
+		if n := 21 + (severity-600)/100; n < 24 {
+			return n
+		}
+		return 24
+	}
+}
+
+// newLogRecord converts an entry into a record.
+func newLogRecord(t time.Time, name string, level hclog.Level, msg string, args []interface{}) otlpLogRecord {
+	scope := name
+	if scope == "" {
+		scope = DefaultScope
+	}
+
+	ts := strconv.FormatInt(t.UnixNano(), 10)
+
+	rec := otlpLogRecord{
+		scope:                scope,
+		TimeUnixNano:         ts,
+		ObservedTimeUnixNano: ts,
+		SeverityNumber:       SeverityNumber(level),
+		SeverityText:         level.String(),
+		Body:                 stringValue(msg),
+	}
+
+	if len(args)%2 != 0 {
+		last := args[len(args)-1]
+		args = args[:len(args)-1]
+
+		key := hclog.MissingKey
+		if _, ok := last.(hclog.CapturedStacktrace); ok {
+			key = "exception.stacktrace"
+		}
+		args = append(args[:len(args):len(args)], key, last)
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		key := fmt.Sprint(args[i])
+		val := args[i+1]
+
+		switch key {
+		case TraceIDKey:
+			if s, ok := val.(string); ok && isHexID(s, 16) {
+				rec.TraceID = s
+				continue
+			}
+		case SpanIDKey:
+			if s, ok := val.(string); ok && isHexID(s, 8) {
+				rec.SpanID = s
+				continue
+			}
+		case TraceFlagsKey:
+			if s, ok := val.(string); ok {
+				if flags, err := strconv.ParseUint(s, 16, 8); err == nil {
+					rec.Flags = uint32(flags)
+					continue
+				}
+			}
+		}
+
+		rec.Attributes = append(rec.Attributes, otlpKeyValue{Key: key, Value: anyValue(val)})
+	}
+
+	return rec
+}
+
+// isHexID reports whether s is the hex encoding of an id of size bytes.
+func isHexID(s string, size int) bool {
+	if len(s) != size*2 {
+		return false
+	}
+
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// anyValue converts a value from a log call, keeping the type of strings,
+// booleans, numbers and slices of them, and formatting anything else as a
+// string.
+func anyValue(val interface{}) otlpAnyValue {
+	switch v := val.(type) {
+	case nil:
+		return otlpAnyValue{}
+	case string:
+		return stringValue(v)
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case time.Duration:
+		return stringValue(v.String())
+	case hclog.Format:
+		return stringValue(fmt.Sprintf(v[0].(string), v[1:]...))
+	case error:
+		return stringValue(v.Error())
+	case fmt.Stringer:
+		return stringValue(v.String())
+	case []byte:
+		return stringValue(string(v))
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s := strconv.FormatInt(rv.Int(), 10)
+		return otlpAnyValue{IntValue: &s}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s := strconv.FormatUint(rv.Uint(), 10)
+		return otlpAnyValue{IntValue: &s}
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		return otlpAnyValue{DoubleValue: &f}
+	case reflect.String:
+		return stringValue(rv.String())
+	case reflect.Slice, reflect.Array:
+		values := make([]otlpAnyValue, rv.Len())
+		for i := range values {
+			values[i] = anyValue(rv.Index(i).Interface())
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	default:
+		return stringValue(fmt.Sprintf("%+v", val))
+	}
+}
+
+// attributeValue converts an attribute value for the resource.
+func attributeValue(v attribute.Value) otlpAnyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		return otlpAnyValue{BoolValue: &b}
+	case attribute.INT64:
+		s := strconv.FormatInt(v.AsInt64(), 10)
+		return otlpAnyValue{IntValue: &s}
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		return otlpAnyValue{DoubleValue: &f}
+	case attribute.STRING:
+		return stringValue(v.AsString())
+	default:
+		return anyValue(v.AsInterface())
+	}
+}
+
+func stringValue(s string) otlpAnyValue {
+	return otlpAnyValue{StringValue: &s}
+}
+
+// These are the parts of an OTLP ExportLogsServiceRequest in its JSON
+// encoding, in which 64 bit integers are strings and trace and span ids are
+// hex.
+
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	// scope is the instrumentation scope the record is grouped under.
+	scope string
+
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber,omitempty"`
+	SeverityText         string         `json:"severityText,omitempty"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	Flags                uint32         `json:"flags,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
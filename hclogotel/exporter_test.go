@@ -0,0 +1,342 @@
+package hclogotel
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TerminusDeus/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// receiver is an OTLP/HTTP logs endpoint that records the requests it gets.
+type receiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []map[string]interface{}
+
+	// statuses are returned for the first requests, before 200 OK.
+	statuses []int
+}
+
+func newReceiver(t *testing.T) (*receiver, *httptest.Server) {
+	r := &receiver{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if len(r.statuses) > 0 {
+			w.WriteHeader(r.statuses[0])
+			r.statuses = r.statuses[1:]
+			return
+		}
+
+		data, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &body))
+
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return r, srv
+}
+
+// records returns the records received, in order, with the name of their
+// scope added under "scope".
+func (r *receiver) records() []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var records []map[string]interface{}
+	for _, body := range r.bodies {
+		for _, rl := range body["resourceLogs"].([]interface{}) {
+			for _, sl := range rl.(map[string]interface{})["scopeLogs"].([]interface{}) {
+				sl := sl.(map[string]interface{})
+				scope := sl["scope"].(map[string]interface{})["name"]
+				for _, rec := range sl["logRecords"].([]interface{}) {
+					rec := rec.(map[string]interface{})
+					rec["scope"] = scope
+					records = append(records, rec)
+				}
+			}
+		}
+	}
+
+	return records
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.bodies)
+}
+
+func newExporter(t *testing.T, opts *ExporterOptions) *LogExporter {
+	e, err := NewLogExporter(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { e.Close() })
+
+	return e
+}
+
+func TestLogExporter(t *testing.T) {
+	t.Run("sends records to the collector", func(t *testing.T) {
+		r, srv := newReceiver(t)
+
+		e := newExporter(t, &ExporterOptions{
+			Endpoint:    srv.URL,
+			Headers:     map[string]string{"Authorization": "Bearer abc"},
+			ServiceName: "my-app",
+			Resource:    []attribute.KeyValue{attribute.String("host.name", "web-1")},
+		})
+
+		e.Accept("my-app.db", hclog.Warn, "slow query", "took_ms", 250, "retry", true, "ratio", 0.5, "tables", []string{"a", "b"})
+		require.NoError(t, e.Close())
+
+		require.Equal(t, 1, r.count())
+		assert.Equal(t, "application/json", r.requests[0].Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer abc", r.requests[0].Header.Get("Authorization"))
+
+		resource := r.bodies[0]["resourceLogs"].([]interface{})[0].(map[string]interface{})["resource"]
+		assert.Equal(t, map[string]interface{}{
+			"attributes": []interface{}{
+				map[string]interface{}{"key": "host.name", "value": map[string]interface{}{"stringValue": "web-1"}},
+				map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "my-app"}},
+			},
+		}, resource)
+
+		records := r.records()
+		require.Len(t, records, 1)
+
+		rec := records[0]
+		assert.Equal(t, "my-app.db", rec["scope"])
+		assert.Equal(t, float64(13), rec["severityNumber"])
+		assert.Equal(t, "warn", rec["severityText"])
+		assert.Equal(t, map[string]interface{}{"stringValue": "slow query"}, rec["body"])
+		assert.NotEmpty(t, rec["timeUnixNano"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"key": "took_ms", "value": map[string]interface{}{"intValue": "250"}},
+			map[string]interface{}{"key": "retry", "value": map[string]interface{}{"boolValue": true}},
+			map[string]interface{}{"key": "ratio", "value": map[string]interface{}{"doubleValue": 0.5}},
+			map[string]interface{}{"key": "tables", "value": map[string]interface{}{"arrayValue": map[string]interface{}{
+				"values": []interface{}{
+					map[string]interface{}{"stringValue": "a"},
+					map[string]interface{}{"stringValue": "b"},
+				},
+			}}},
+		}, rec["attributes"])
+	})
+
+	t.Run("groups records by scope", func(t *testing.T) {
+		r, srv := newReceiver(t)
+
+		e := newExporter(t, &ExporterOptions{Endpoint: srv.URL})
+
+		e.Accept("a", hclog.Info, "one")
+		e.Accept("", hclog.Info, "two")
+		e.Accept("a", hclog.Info, "three")
+		require.NoError(t, e.Flush())
+
+		require.Equal(t, 1, r.count())
+
+		scopes := r.bodies[0]["resourceLogs"].([]interface{})[0].(map[string]interface{})["scopeLogs"].([]interface{})
+		require.Len(t, scopes, 2)
+
+		var names []interface{}
+		for _, rec := range r.records() {
+			names = append(names, rec["scope"], rec["body"].(map[string]interface{})["stringValue"])
+		}
+		assert.Equal(t, []interface{}{"a", "one", "a", "three", DefaultScope, "two"}, names)
+	})
+
+	t.Run("uses the trace fields as the trace context", func(t *testing.T) {
+		r, srv := newReceiver(t)
+
+		e := newExporter(t, &ExporterOptions{Endpoint: srv.URL})
+
+		_, span, _ := startSpan(t)
+		sc := span.SpanContext()
+
+		e.Accept("test", hclog.Error, "failed", append(Fields(sc), "error", "boom")...)
+		require.NoError(t, e.Flush())
+
+		records := r.records()
+		require.Len(t, records, 1)
+		assert.Equal(t, sc.TraceID().String(), records[0]["traceId"])
+		assert.Equal(t, sc.SpanID().String(), records[0]["spanId"])
+		assert.Equal(t, float64(1), records[0]["flags"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"key": "error", "value": map[string]interface{}{"stringValue": "boom"}},
+		}, records[0]["attributes"])
+	})
+
+	t.Run("sends full batches without waiting", func(t *testing.T) {
+		r, srv := newReceiver(t)
+
+		e := newExporter(t, &ExporterOptions{
+			Endpoint:      srv.URL,
+			BatchSize:     2,
+			FlushInterval: time.Hour,
+		})
+
+		for i := 0; i < 5; i++ {
+			e.Accept("test", hclog.Info, "entry", "i", i)
+		}
+
+		require.Eventually(t, func() bool { return r.count() == 2 }, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, e.Close())
+		assert.Equal(t, 3, r.count())
+		assert.Len(t, r.records(), 5)
+	})
+
+	t.Run("sends batches after the interval", func(t *testing.T) {
+		r, srv := newReceiver(t)
+
+		e := newExporter(t, &ExporterOptions{
+			Endpoint:      srv.URL,
+			FlushInterval: 10 * time.Millisecond,
+		})
+
+		e.Accept("test", hclog.Info, "entry")
+
+		require.Eventually(t, func() bool { return r.count() == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("retries when the collector is unavailable", func(t *testing.T) {
+		r, srv := newReceiver(t)
+		r.statuses = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+
+		e := newExporter(t, &ExporterOptions{Endpoint: srv.URL})
+
+		e.Accept("test", hclog.Info, "entry")
+		require.NoError(t, e.Flush())
+
+		assert.Len(t, r.records(), 1)
+	})
+
+	t.Run("reports errors that can't be retried", func(t *testing.T) {
+		r, srv := newReceiver(t)
+		r.statuses = []int{http.StatusBadRequest}
+
+		var errs []error
+
+		e := newExporter(t, &ExporterOptions{
+			Endpoint: srv.URL,
+			OnError:  func(err error) { errs = append(errs, err) },
+		})
+
+		e.Accept("test", hclog.Info, "entry")
+		err := e.Flush()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+		assert.Equal(t, []error{err}, errs)
+
+		assert.Empty(t, r.records())
+	})
+
+	t.Run("drops records once closed", func(t *testing.T) {
+		_, srv := newReceiver(t)
+
+		e := newExporter(t, &ExporterOptions{Endpoint: srv.URL})
+		require.NoError(t, e.Close())
+
+		e.Accept("test", hclog.Info, "entry")
+		assert.Equal(t, uint64(1), e.Dropped())
+		assert.Equal(t, errExporterClosed, e.Flush())
+	})
+
+	t.Run("is registered on an intercept logger", func(t *testing.T) {
+		r, srv := newReceiver(t)
+
+		e := newExporter(t, &ExporterOptions{Endpoint: srv.URL})
+
+		var buf bytes.Buffer
+		logger := hclog.NewInterceptLogger(&hclog.LoggerOptions{
+			Name:   "my-app",
+			Output: &buf,
+		})
+		logger.RegisterSink(e)
+
+		logger.Named("http").With("request_id", "7f3a").Info("handled", "status", 200)
+		require.NoError(t, e.Flush())
+
+		records := r.records()
+		require.Len(t, records, 1)
+		assert.Equal(t, "my-app.http", records[0]["scope"])
+		assert.Equal(t, float64(9), records[0]["severityNumber"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"key": "request_id", "value": map[string]interface{}{"stringValue": "7f3a"}},
+			map[string]interface{}{"key": "status", "value": map[string]interface{}{"intValue": "200"}},
+		}, records[0]["attributes"])
+	})
+
+	t.Run("ignores entries below its level", func(t *testing.T) {
+		r, srv := newReceiver(t)
+
+		e := newExporter(t, &ExporterOptions{Endpoint: srv.URL})
+
+		var buf bytes.Buffer
+		logger := hclog.NewInterceptLogger(&hclog.LoggerOptions{
+			Output: &buf,
+			Level:  hclog.Info,
+		})
+		logger.RegisterSink(e)
+
+		logger.Debug("cache miss", "key", "a")
+		require.NoError(t, e.Flush())
+
+		assert.Equal(t, 0, r.count())
+		assert.Equal(t, uint64(0), e.Dropped())
+
+		logger.Warn("cache full")
+		require.NoError(t, e.Flush())
+
+		records := r.records()
+		require.Len(t, records, 1)
+		assert.Equal(t, map[string]interface{}{"stringValue": "cache full"}, records[0]["body"])
+	})
+
+	t.Run("exports entries from its level", func(t *testing.T) {
+		r, srv := newReceiver(t)
+
+		e := newExporter(t, &ExporterOptions{Endpoint: srv.URL, Level: hclog.Warn})
+
+		e.Accept("test", hclog.Info, "started")
+		e.Accept("test", hclog.Error, "failed")
+		require.NoError(t, e.Flush())
+
+		records := r.records()
+		require.Len(t, records, 1)
+		assert.Equal(t, "error", records[0]["severityText"])
+	})
+}
+
+func TestSeverityNumber(t *testing.T) {
+	assert.Equal(t, 1, SeverityNumber(hclog.Trace))
+	assert.Equal(t, 5, SeverityNumber(hclog.Debug))
+	assert.Equal(t, 9, SeverityNumber(hclog.Info))
+	assert.Equal(t, 13, SeverityNumber(hclog.Warn))
+	assert.Equal(t, 17, SeverityNumber(hclog.Error))
+	assert.Equal(t, 21, SeverityNumber(hclog.Panic))
+	assert.Equal(t, 22, SeverityNumber(hclog.Fatal))
+	assert.Equal(t, 0, SeverityNumber(hclog.Off))
+	assert.Equal(t, 0, SeverityNumber(hclog.NoLevel))
+	assert.Equal(t, 0, SeverityNumber(hclog.Level(1000)))
+
+	withTestLevels(t)
+	assert.Equal(t, 11, SeverityNumber(testNotice))
+}
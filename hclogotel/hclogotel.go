@@ -0,0 +1,253 @@
+// Package hclogotel correlates hclog output with OpenTelemetry traces.
+//
+// Entries logged with a context carrying a span get the trace_id, span_id
+// and trace_flags of the span, either by registering Extractor for use with
+// hclog.InfoCtx and the other *Ctx functions, or by storing a logger for the
+// span in the context with WithContext. Loggers from WithContext can also
+// record entries as events on the span.
+package hclogotel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/TerminusDeus/go-hclog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The keys the trace fields are logged with.
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// Fields returns the key/value pairs for sc, or nil if it isn't valid.
+func Fields(sc trace.SpanContext) []interface{} {
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []interface{}{
+		TraceIDKey, sc.TraceID().String(),
+		SpanIDKey, sc.SpanID().String(),
+		TraceFlagsKey, sc.TraceFlags().String(),
+	}
+}
+
+// Extractor is an hclog.ContextExtractor that adds the trace fields of the
+// span in the context. Register it once to add them to every entry logged
+// with hclog.InfoCtx and the other *Ctx functions, and with log/slog through
+// hclog.NewSlogHandler:
+//
+//	hclog.RegisterContextExtractor(hclogotel.Extractor)
+func Extractor(ctx context.Context) []interface{} {
+	return Fields(trace.SpanContextFromContext(ctx))
+}
+
+// Options can be used to configure the loggers returned by Logger and stored
+// by WithContext.
+type Options struct {
+	// Record entries as events on the span.
+	RecordEvents bool
+
+	// The least severe level recorded as events, compared by severity so
+	// that levels added with hclog.RegisterLevel are recorded too. Entries
+	// the logger doesn't emit because of its own level aren't recorded
+	// either. Defaults to hclog.Warn.
+	EventLevel hclog.Level
+}
+
+// Logger returns logger with the trace fields of the span in ctx. If there
+// is no valid span, logger is returned as it is.
+//
+// With RecordEvents, entries at EventLevel or above are also recorded as
+// events named "log" on the span, with the level and message as the
+// log.severity and log.message attributes and the key/value pairs from the
+// log call as further attributes. The returned logger is a wrapper around
+// logger, which passes on the location of its caller for IncludeLocation.
+func Logger(ctx context.Context, logger hclog.Logger, opts *Options) hclog.Logger {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	span := trace.SpanFromContext(ctx)
+
+	fields := Fields(span.SpanContext())
+	if fields == nil {
+		return logger
+	}
+
+	logger = logger.With(fields...)
+
+	if !opts.RecordEvents {
+		return logger
+	}
+
+	level := opts.EventLevel
+	if level == hclog.NoLevel {
+		level = hclog.Warn
+	}
+
+	return &spanLogger{
+		Logger:   logger,
+		span:     span,
+		minLevel: level.Severity(),
+	}
+}
+
+// WithContext stores the logger returned by Logger in the context, with
+// hclog.WithContext, so that hclog.FromContext returns it.
+func WithContext(ctx context.Context, logger hclog.Logger, opts *Options) context.Context {
+	return hclog.WithContext(ctx, Logger(ctx, logger, opts))
+}
+
+// spanLogger records entries as events on a span, then passes them on to the
+// Logger.
+type spanLogger struct {
+	hclog.Logger
+
+	span     trace.Span
+	minLevel int32
+}
+
+var _ hclog.Logger = &spanLogger{}
+var _ hclog.FatalLogger = &spanLogger{}
+var _ hclog.LevelGetter = &spanLogger{}
+var _ hclog.Flushable = &spanLogger{}
+
+// record adds an event for the entry to the span, if the level is severe
+// enough, the logger emits the entry and the span is still recording.
+func (l *spanLogger) record(level hclog.Level, msg string, args []interface{}) {
+	if level == hclog.NoLevel || level == hclog.Off || level.Severity() < l.minLevel {
+		return
+	}
+
+	if !hclog.IsLevelEnabled(l.Logger, level) || !l.span.IsRecording() {
+		return
+	}
+
+	attrs := make([]attribute.KeyValue, 0, 2+len(args)/2)
+	attrs = append(attrs,
+		attribute.String("log.severity", level.String()),
+		attribute.String("log.message", msg),
+	)
+
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs = append(attrs, eventAttribute(fmt.Sprint(args[i]), args[i+1]))
+	}
+
+	l.span.AddEvent("log", trace.WithAttributes(attrs...))
+}
+
+// eventAttribute converts a key/value pair into an attribute, keeping the
+// type of basic values and formatting anything else as a string.
+func eventAttribute(key string, val interface{}) attribute.KeyValue {
+	switch v := val.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case int32:
+		return attribute.Int64(key, int64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case float32:
+		return attribute.Float64(key, float64(v))
+	case error:
+		return attribute.String(key, v.Error())
+	case hclog.Format:
+		return attribute.String(key, fmt.Sprintf(v[0].(string), v[1:]...))
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
+
+// log records the entry, then logs it with the location of the caller of
+// the exported method, which must call log directly.
+func (l *spanLogger) log(level hclog.Level, msg string, args []interface{}) {
+	l.record(level, msg, args)
+
+	// Skip runtime.Callers, log and the exported method.
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+
+	hclog.LogAt(l.Logger, level, pcs[0], msg, args...)
+}
+
+func (l *spanLogger) Log(level hclog.Level, msg string, args ...interface{}) {
+	l.log(level, msg, args)
+}
+
+func (l *spanLogger) Trace(msg string, args ...interface{}) {
+	l.log(hclog.Trace, msg, args)
+}
+
+func (l *spanLogger) Debug(msg string, args ...interface{}) {
+	l.log(hclog.Debug, msg, args)
+}
+
+func (l *spanLogger) Info(msg string, args ...interface{}) {
+	l.log(hclog.Info, msg, args)
+}
+
+func (l *spanLogger) Warn(msg string, args ...interface{}) {
+	l.log(hclog.Warn, msg, args)
+}
+
+func (l *spanLogger) Error(msg string, args ...interface{}) {
+	l.log(hclog.Error, msg, args)
+}
+
+func (l *spanLogger) Fatal(msg string, args ...interface{}) {
+	l.log(hclog.Fatal, msg, args)
+	l.Flush()
+	os.Exit(1)
+}
+
+func (l *spanLogger) Panic(msg string, args ...interface{}) {
+	l.log(hclog.Panic, msg, args)
+	l.Flush()
+	panic(msg)
+}
+
+// GetLevel returns the level of the wrapped logger, or NoLevel if it doesn't
+// report one.
+func (l *spanLogger) GetLevel() hclog.Level {
+	if g, ok := l.Logger.(hclog.LevelGetter); ok {
+		return g.GetLevel()
+	}
+
+	return hclog.NoLevel
+}
+
+// Flush flushes the wrapped logger, if it is Flushable.
+func (l *spanLogger) Flush() error {
+	if f, ok := l.Logger.(hclog.Flushable); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+// The methods deriving loggers wrap the result, so that it records events
+// on the same span.
+
+func (l *spanLogger) With(args ...interface{}) hclog.Logger {
+	return &spanLogger{Logger: l.Logger.With(args...), span: l.span, minLevel: l.minLevel}
+}
+
+func (l *spanLogger) Named(name string) hclog.Logger {
+	return &spanLogger{Logger: l.Logger.Named(name), span: l.span, minLevel: l.minLevel}
+}
+
+func (l *spanLogger) ResetNamed(name string) hclog.Logger {
+	return &spanLogger{Logger: l.Logger.ResetNamed(name), span: l.span, minLevel: l.minLevel}
+}
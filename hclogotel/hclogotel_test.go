@@ -0,0 +1,257 @@
+package hclogotel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/TerminusDeus/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts a span recorded by the returned recorder.
+func startSpan(t *testing.T) (context.Context, trace.Span, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	return ctx, span, recorder
+}
+
+func newLogger(buf *bytes.Buffer) hclog.Logger {
+	return hclog.New(&hclog.LoggerOptions{
+		Name:        "test",
+		Level:       hclog.Trace,
+		Output:      buf,
+		DisableTime: true,
+	})
+}
+
+// testNotice is a level registered by the tests, between Info and Warn.
+const testNotice hclog.Level = 100
+
+var registerTestLevels sync.Once
+
+// withTestLevels registers testNotice, which can only be done once per
+// process.
+func withTestLevels(t *testing.T) {
+	registerTestLevels.Do(func() {
+		require.NoError(t, hclog.RegisterLevel(testNotice, hclog.LevelDefinition{
+			Name:     "notice",
+			Severity: 350,
+		}))
+	})
+}
+
+func TestFields(t *testing.T) {
+	ctx, span, _ := startSpan(t)
+	sc := span.SpanContext()
+
+	assert.Equal(t, []interface{}{
+		"trace_id", sc.TraceID().String(),
+		"span_id", sc.SpanID().String(),
+		"trace_flags", "01",
+	}, Extractor(ctx))
+
+	assert.Nil(t, Extractor(context.Background()))
+}
+
+var registerExtractor sync.Once
+
+func TestExtractor(t *testing.T) {
+	registerExtractor.Do(func() {
+		hclog.RegisterContextExtractor(Extractor)
+	})
+
+	var buf bytes.Buffer
+
+	ctx, span, _ := startSpan(t)
+	ctx = hclog.WithContext(ctx, newLogger(&buf))
+
+	hclog.InfoCtx(ctx, "handled", "status", 200)
+
+	sc := span.SpanContext()
+	assert.Equal(t, "[INFO]  test: handled: trace_id="+sc.TraceID().String()+" span_id="+sc.SpanID().String()+" trace_flags=01 status=200\n", buf.String())
+}
+
+func TestWithContext(t *testing.T) {
+	t.Run("adds the trace fields to every line", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		ctx, span, recorder := startSpan(t)
+		ctx = WithContext(ctx, newLogger(&buf), nil)
+
+		hclog.FromContext(ctx).Info("handled")
+		hclog.FromContext(ctx).Named("db").Warn("slow query")
+		span.End()
+
+		sc := span.SpanContext()
+
+		// With sorts the fields by key.
+		assert.Equal(t, strings.Join([]string{
+			"[INFO]  test: handled: span_id=" + sc.SpanID().String() + " trace_flags=01 trace_id=" + sc.TraceID().String(),
+			"[WARN]  test.db: slow query: span_id=" + sc.SpanID().String() + " trace_flags=01 trace_id=" + sc.TraceID().String(),
+			"",
+		}, "\n"), buf.String())
+
+		require.Len(t, recorder.Ended(), 1)
+		assert.Empty(t, recorder.Ended()[0].Events())
+	})
+
+	t.Run("leaves the logger as it is without a span", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := newLogger(&buf)
+		assert.Equal(t, logger, Logger(context.Background(), logger, &Options{RecordEvents: true}))
+	})
+
+	t.Run("records events on the span", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		ctx, span, recorder := startSpan(t)
+		ctx = WithContext(ctx, newLogger(&buf), &Options{RecordEvents: true})
+
+		logger := hclog.FromContext(ctx)
+		logger.Info("handled")
+		logger.With("component", "db").Warn("slow query", "took_ms", 250, "retry", true)
+		logger.Named("auth").Error("login failed", "error", errors.New("bad password"), "user", hclog.Fmt("id-%d", 7))
+		logger.Log(hclog.Error, "via log")
+		span.End()
+
+		assert.Equal(t, 4, strings.Count(buf.String(), "\n"))
+
+		require.Len(t, recorder.Ended(), 1)
+		events := recorder.Ended()[0].Events()
+		require.Len(t, events, 3)
+
+		assert.Equal(t, "log", events[0].Name)
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("log.severity", "warn"),
+			attribute.String("log.message", "slow query"),
+			attribute.Int("took_ms", 250),
+			attribute.Bool("retry", true),
+		}, events[0].Attributes)
+
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("log.severity", "error"),
+			attribute.String("log.message", "login failed"),
+			attribute.String("error", "bad password"),
+			attribute.String("user", "id-7"),
+		}, events[1].Attributes)
+
+		assert.Equal(t, attribute.String("log.message", "via log"), events[2].Attributes[1])
+	})
+
+	t.Run("uses the event level", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		ctx, span, recorder := startSpan(t)
+		ctx = WithContext(ctx, newLogger(&buf), &Options{
+			RecordEvents: true,
+			EventLevel:   hclog.Debug,
+		})
+
+		hclog.FromContext(ctx).Trace("trace")
+		hclog.FromContext(ctx).Debug("debug")
+		span.End()
+
+		// Entries after the span has ended are logged, but not recorded.
+		hclog.FromContext(ctx).Error("late")
+
+		require.Len(t, recorder.Ended(), 1)
+		events := recorder.Ended()[0].Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, attribute.String("log.message", "debug"), events[0].Attributes[1])
+		assert.Contains(t, buf.String(), "late")
+	})
+
+	t.Run("skips entries the logger doesn't emit", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		ctx, span, recorder := startSpan(t)
+		ctx = WithContext(ctx, hclog.New(&hclog.LoggerOptions{
+			Level:  hclog.Error,
+			Output: &buf,
+		}), &Options{RecordEvents: true})
+
+		hclog.FromContext(ctx).Warn("hidden")
+		hclog.FromContext(ctx).Error("shown")
+		span.End()
+
+		require.Len(t, recorder.Ended(), 1)
+		events := recorder.Ended()[0].Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, attribute.String("log.message", "shown"), events[0].Attributes[1])
+		assert.NotContains(t, buf.String(), "hidden")
+	})
+
+	t.Run("records registered levels by severity", func(t *testing.T) {
+		withTestLevels(t)
+
+		var buf bytes.Buffer
+
+		ctx, span, recorder := startSpan(t)
+		ctx = WithContext(ctx, newLogger(&buf), &Options{
+			RecordEvents: true,
+			EventLevel:   hclog.Info,
+		})
+
+		hclog.FromContext(ctx).Debug("debug")
+		hclog.FromContext(ctx).Log(testNotice, "noticed")
+		span.End()
+
+		require.Len(t, recorder.Ended(), 1)
+		events := recorder.Ended()[0].Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, attribute.String("log.severity", "notice"), events[0].Attributes[0])
+	})
+	t.Run("reports the location of its caller", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		ctx, span, _ := startSpan(t)
+		defer span.End()
+
+		ctx = WithContext(ctx, hclog.New(&hclog.LoggerOptions{
+			Output:          &buf,
+			DisableTime:     true,
+			IncludeLocation: true,
+		}), &Options{RecordEvents: true})
+
+		logger := hclog.FromContext(ctx)
+		_, _, line, _ := runtime.Caller(0)
+		logger.Info("handled")
+		logger.With("component", "db").Log(hclog.Warn, "slow query")
+
+		lines := strings.Split(buf.String(), "\n")
+		require.Len(t, lines, 3)
+		assert.True(t, strings.HasPrefix(lines[0], fmt.Sprintf("[INFO]  hclogotel/hclogotel_test.go:%d: handled:", line+1)), lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], fmt.Sprintf("[WARN]  hclogotel/hclogotel_test.go:%d: slow query:", line+2)), lines[1])
+	})
+
+	t.Run("passes on the level and flushing of the logger", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		ctx, span, _ := startSpan(t)
+		defer span.End()
+
+		ctx = WithContext(ctx, newLogger(&buf), &Options{RecordEvents: true})
+		logger := hclog.FromContext(ctx)
+
+		require.Implements(t, (*hclog.LevelGetter)(nil), logger)
+		require.Implements(t, (*hclog.Flushable)(nil), logger)
+
+		assert.Equal(t, hclog.Trace, logger.(hclog.LevelGetter).GetLevel())
+		assert.NoError(t, logger.(hclog.Flushable).Flush())
+	})
+}
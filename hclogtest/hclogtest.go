@@ -0,0 +1,344 @@
+// Package hclogtest provides an hclog.Logger for tests that records the
+// entries logged with it, so that tests can make assertions about them
+// instead of scraping the text written to an output.
+package hclogtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TerminusDeus/go-hclog"
+)
+
+// Options can be used to configure a Logger.
+type Options struct {
+	// Name of the logger.
+	Name string
+
+	// The threshold for the logger. Anything less severe is not recorded.
+	// Defaults to hclog.Trace.
+	Level hclog.Level
+
+	// IndependentLevels causes loggers derived with Named and With to have
+	// their own copy of the level, as with hclog.LoggerOptions.
+	IndependentLevels bool
+
+	// TB, if set, is given every entry as a line, so that the output of a
+	// test shows what it logged when it fails or is run with -v. Each line
+	// starts with the location of the log call. Entries logged after the
+	// test has finished are only recorded.
+	TB testing.TB
+}
+
+// Pair is a key/value pair of an Entry.
+type Pair struct {
+	Key   string
+	Value interface{}
+}
+
+// Entry is an entry recorded by a Logger.
+type Entry struct {
+	Time    time.Time
+	Level   hclog.Level
+	Name    string
+	Message string
+
+	// Args holds the key/value pairs given to With followed by those given
+	// to the log call, in order. A value without a key is paired with
+	// hclog.MissingKey.
+	Args []Pair
+
+	// Caller is where the entry was logged from, if it could be found.
+	Caller *runtime.Frame
+}
+
+// Value returns the value of the last pair with the given key.
+func (e Entry) Value(key string) (interface{}, bool) {
+	for i := len(e.Args) - 1; i >= 0; i-- {
+		if e.Args[i].Key == key {
+			return e.Args[i].Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Matches reports whether the entry has the given level and message, and
+// the given key/value pairs among its Args. The values are compared with
+// reflect.DeepEqual.
+func (e Entry) Matches(level hclog.Level, msg string, args ...interface{}) bool {
+	if e.Level != level || e.Message != msg {
+		return false
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		key := fmt.Sprint(args[i])
+
+		var want interface{}
+		if i+1 < len(args) {
+			want = args[i+1]
+		}
+
+		got, ok := e.Value(key)
+		if !ok || !reflect.DeepEqual(got, want) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String formats the entry like the plain output of hclog, without the
+// time.
+func (e Entry) String() string {
+	var buf bytes.Buffer
+
+	buf.WriteByte('[')
+	buf.WriteString(strings.ToUpper(e.Level.String()))
+	buf.WriteByte(']')
+
+	if e.Caller != nil {
+		fmt.Fprintf(&buf, " %s:%d:", filepath.Base(e.Caller.File), e.Caller.Line)
+	}
+
+	buf.WriteByte(' ')
+	if e.Name != "" {
+		buf.WriteString(e.Name)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(e.Message)
+
+	if len(e.Args) > 0 {
+		buf.WriteByte(':')
+	}
+
+	for _, p := range e.Args {
+		val := fmt.Sprint(p.Value)
+		if f, ok := p.Value.(hclog.Format); ok {
+			val = fmt.Sprintf(f[0].(string), f[1:]...)
+		}
+
+		if val == "" || strings.ContainsAny(val, " \t\n\"=") {
+			val = strconv.Quote(val)
+		}
+
+		buf.WriteByte(' ')
+		buf.WriteString(p.Key)
+		buf.WriteByte('=')
+		buf.WriteString(val)
+	}
+
+	return buf.String()
+}
+
+// Logger is an hclog.Logger that records the entries logged with it, and
+// with the loggers derived from it with Named, With and the other methods,
+// which behave as they do for a Logger created by hclog.New.
+type Logger struct {
+	hclog.Logger
+
+	rec *recorder
+}
+
+var _ hclog.Logger = &Logger{}
+
+// New returns a Logger.
+func New(opts *Options) *Logger {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	level := opts.Level
+	if level == hclog.NoLevel {
+		level = hclog.Trace
+	}
+
+	rec := &recorder{tb: opts.TB}
+	if opts.TB != nil {
+		opts.TB.Cleanup(rec.stop)
+	}
+
+	return &Logger{
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:              opts.Name,
+			Level:             level,
+			Output:            ioutil.Discard,
+			Encoder:           rec,
+			IncludeLocation:   true,
+			IndependentLevels: opts.IndependentLevels,
+		}),
+		rec: rec,
+	}
+}
+
+// Entries returns the entries recorded so far, oldest first.
+func (l *Logger) Entries() []Entry {
+	l.rec.mu.Lock()
+	defer l.rec.mu.Unlock()
+
+	entries := make([]Entry, len(l.rec.entries))
+	copy(entries, l.rec.entries)
+
+	return entries
+}
+
+// Reset discards the entries recorded so far.
+func (l *Logger) Reset() {
+	l.rec.mu.Lock()
+	defer l.rec.mu.Unlock()
+
+	l.rec.entries = nil
+}
+
+// Logged reports whether an entry matching the level, message and key/value
+// pairs has been recorded. See Entry.Matches.
+func (l *Logger) Logged(level hclog.Level, msg string, args ...interface{}) bool {
+	for _, e := range l.Entries() {
+		if e.Matches(level, msg, args...) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AssertLogged fails the test unless an entry matching the level, message
+// and key/value pairs has been recorded. It returns whether there was one.
+//
+//	logger.AssertLogged(t, hclog.Warn, "retrying", "attempt", 2)
+func (l *Logger) AssertLogged(t testing.TB, level hclog.Level, msg string, args ...interface{}) bool {
+	t.Helper()
+
+	if l.Logged(level, msg, args...) {
+		return true
+	}
+
+	t.Errorf("no entry matching %s; recorded:\n%s", describe(level, msg, args), l.dump())
+	return false
+}
+
+// AssertNotLogged fails the test if an entry matching the level, message and
+// key/value pairs has been recorded. It returns whether there was none.
+func (l *Logger) AssertNotLogged(t testing.TB, level hclog.Level, msg string, args ...interface{}) bool {
+	t.Helper()
+
+	if !l.Logged(level, msg, args...) {
+		return true
+	}
+
+	t.Errorf("unexpected entry matching %s; recorded:\n%s", describe(level, msg, args), l.dump())
+	return false
+}
+
+// dump formats the recorded entries for a failure message.
+func (l *Logger) dump() string {
+	entries := l.Entries()
+	if len(entries) == 0 {
+		return "  (none)"
+	}
+
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = "  " + e.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// describe formats what an assertion looked for.
+func describe(level hclog.Level, msg string, args []interface{}) string {
+	s := fmt.Sprintf("[%s] %q", strings.ToUpper(level.String()), msg)
+	for i := 0; i < len(args); i += 2 {
+		var val interface{}
+		if i+1 < len(args) {
+			val = args[i+1]
+		}
+		s += fmt.Sprintf(" %v=%#v", args[i], val)
+	}
+
+	return s
+}
+
+// recorder is the hclog.Encoder of a Logger, shared with the loggers derived
+// from it.
+type recorder struct {
+	tb testing.TB
+
+	mu      sync.Mutex
+	entries []Entry
+
+	// stopped is set once the test has finished, as passing lines to it
+	// after that panics.
+	stopped bool
+}
+
+var _ hclog.Encoder = &recorder{}
+
+// Encode records the entry, and writes nothing to buf.
+func (r *recorder) Encode(buf *bytes.Buffer, t time.Time, name string, level hclog.Level, msg string, caller *runtime.Frame, implied, args []interface{}) error {
+	all := make([]interface{}, 0, len(implied)+len(args)+1)
+	all = append(all, implied...)
+	all = append(all, args...)
+
+	if len(all)%2 != 0 {
+		extra := all[len(all)-1]
+		all = append(all[:len(all)-1], hclog.MissingKey, extra)
+	}
+
+	e := Entry{
+		Time:    t,
+		Level:   level,
+		Name:    name,
+		Message: msg,
+		Args:    make([]Pair, 0, len(all)/2),
+		Caller:  caller,
+	}
+
+	for i := 0; i < len(all); i += 2 {
+		e.Args = append(e.Args, Pair{Key: fmt.Sprint(all[i]), Value: all[i+1]})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, e)
+
+	// The lock is held while passing the line on, so that the test can't
+	// finish in the meantime.
+	if r.tb != nil && !r.stopped {
+		r.forward(e)
+	}
+
+	return nil
+}
+
+// forward passes the entry to the test as a line. Where the test has an
+// Output, the line is written to it as it is, since it starts with the
+// location of the log call already. Otherwise it goes through Log, which
+// can't see past the logger and prefixes it with the location of this call.
+func (r *recorder) forward(e Entry) {
+	if o, ok := r.tb.(interface{ Output() io.Writer }); ok {
+		io.WriteString(o.Output(), e.String()+"\n")
+		return
+	}
+
+	r.tb.Log(e.String())
+}
+
+// stop stops passing lines to the test, once it has finished.
+func (r *recorder) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopped = true
+}
@@ -0,0 +1,198 @@
+package hclogtest
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/TerminusDeus/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTB records what is given to Log, Output and Errorf, and the cleanup
+// functions.
+type fakeTB struct {
+	testing.TB
+
+	logs     []string
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Log(args ...interface{}) {
+	f.logs = append(f.logs, fmt.Sprint(args...))
+}
+
+func (f *fakeTB) Output() io.Writer {
+	return fakeOutput{f}
+}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+// finish runs the cleanup functions, as when the test finishes.
+func (f *fakeTB) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+// fakeOutput records each line written to it as a log.
+type fakeOutput struct {
+	tb *fakeTB
+}
+
+func (o fakeOutput) Write(p []byte) (int, error) {
+	o.tb.logs = append(o.tb.logs, strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("records entries", func(t *testing.T) {
+		logger := New(&Options{Name: "test"})
+
+		_, file, line, _ := runtime.Caller(0)
+		logger.Warn("disk low", "free", 10, "path", "/var")
+
+		entries := logger.Entries()
+		require.Len(t, entries, 1)
+
+		e := entries[0]
+		assert.Equal(t, hclog.Warn, e.Level)
+		assert.Equal(t, "test", e.Name)
+		assert.Equal(t, "disk low", e.Message)
+		assert.Equal(t, []Pair{{"free", 10}, {"path", "/var"}}, e.Args)
+		assert.False(t, e.Time.IsZero())
+
+		require.NotNil(t, e.Caller)
+		assert.Equal(t, file, e.Caller.File)
+		assert.Equal(t, line+1, e.Caller.Line)
+	})
+
+	t.Run("records entries of derived loggers", func(t *testing.T) {
+		logger := New(&Options{Name: "test"})
+
+		logger.Named("db").With("conn", 1).Info("query", "rows", 3, "extra")
+
+		entries := logger.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, "test.db", entries[0].Name)
+		assert.Equal(t, []Pair{{"conn", 1}, {"rows", 3}, {hclog.MissingKey, "extra"}}, entries[0].Args)
+	})
+
+	t.Run("honors the level", func(t *testing.T) {
+		logger := New(&Options{Level: hclog.Info})
+
+		sub := logger.Named("sub")
+		logger.Debug("hidden")
+		sub.SetLevel(hclog.Debug)
+		logger.Debug("shown")
+
+		assert.Len(t, logger.Entries(), 1)
+		assert.True(t, logger.Logged(hclog.Debug, "shown"))
+	})
+
+	t.Run("records the standard logger", func(t *testing.T) {
+		logger := New(nil)
+
+		logger.StandardLogger(&hclog.StandardLoggerOptions{InferLevels: true}).Println("[WARN] from stdlib")
+
+		logger.AssertLogged(t, hclog.Warn, "from stdlib")
+	})
+
+	t.Run("resets", func(t *testing.T) {
+		logger := New(nil)
+
+		logger.Info("one")
+		logger.Reset()
+		logger.Info("two")
+
+		entries := logger.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, "two", entries[0].Message)
+	})
+
+	t.Run("sends lines to the test", func(t *testing.T) {
+		tb := &fakeTB{}
+		logger := New(&Options{Name: "test", TB: tb})
+
+		logger.Info("handled", "status", 200, "path", "/a b")
+
+		require.Len(t, tb.logs, 1)
+
+		_, file, _, _ := runtime.Caller(0)
+		assert.Regexp(t, `^\[INFO\] `+filepath.Base(file)+`:\d+: test: handled: status=200 path="/a b"$`, tb.logs[0])
+	})
+
+	t.Run("stops sending lines once the test has finished", func(t *testing.T) {
+		tb := &fakeTB{}
+		logger := New(&Options{TB: tb})
+
+		logger.Info("during")
+		tb.finish()
+		logger.Info("after")
+
+		assert.Len(t, tb.logs, 1)
+		assert.True(t, logger.Logged(hclog.Info, "after"))
+	})
+
+	t.Run("can be used after a real test has finished", func(t *testing.T) {
+		var logger *Logger
+
+		t.Run("inner", func(t *testing.T) {
+			logger = New(&Options{TB: t})
+			logger.Info("during")
+		})
+
+		assert.NotPanics(t, func() { logger.Info("after") })
+		assert.Len(t, logger.Entries(), 2)
+	})
+}
+
+func TestEntry_Matches(t *testing.T) {
+	e := Entry{
+		Level:   hclog.Warn,
+		Message: "retrying",
+		Args:    []Pair{{"attempt", 1}, {"tags", []string{"a"}}, {"attempt", 2}},
+	}
+
+	assert.True(t, e.Matches(hclog.Warn, "retrying"))
+	assert.True(t, e.Matches(hclog.Warn, "retrying", "attempt", 2))
+	assert.True(t, e.Matches(hclog.Warn, "retrying", "tags", []string{"a"}, "attempt", 2))
+	assert.False(t, e.Matches(hclog.Warn, "retrying", "attempt", 1))
+	assert.False(t, e.Matches(hclog.Warn, "retrying", "attempt", int64(2)))
+	assert.False(t, e.Matches(hclog.Warn, "retrying", "missing", nil))
+	assert.False(t, e.Matches(hclog.Error, "retrying"))
+	assert.False(t, e.Matches(hclog.Warn, "retried"))
+}
+
+func TestLogger_AssertLogged(t *testing.T) {
+	logger := New(&Options{Name: "test"})
+	logger.Warn("retrying", "attempt", 2)
+
+	tb := &fakeTB{}
+
+	assert.True(t, logger.AssertLogged(tb, hclog.Warn, "retrying", "attempt", 2))
+	assert.True(t, logger.AssertNotLogged(tb, hclog.Error, "retrying"))
+	assert.Empty(t, tb.errors)
+
+	assert.False(t, logger.AssertLogged(tb, hclog.Warn, "retrying", "attempt", 3))
+	require.Len(t, tb.errors, 1)
+	assert.Contains(t, tb.errors[0], `no entry matching [WARN] "retrying" attempt=3; recorded:`)
+	assert.Contains(t, tb.errors[0], "test: retrying: attempt=2")
+
+	assert.False(t, logger.AssertNotLogged(tb, hclog.Warn, "retrying"))
+	require.Len(t, tb.errors, 2)
+	assert.Contains(t, tb.errors[1], `unexpected entry matching [WARN] "retrying"`)
+}
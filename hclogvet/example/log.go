@@ -36,4 +36,25 @@ func badHCLog() {
 	il.Error("raft request failed: %v", err)
 	il.Error("error opening file", err)
 	il.Debug("too many connections", numConnections, "ip", ipAddr)
+
+	// bad keys
+	key := "error"
+	reqLogger := l.With("request_id", "5fb446b6")
+
+	l.Error("request failed", err, "error")
+	l.Error("request failed", key, err)
+	l.Debug("too many connections", "ip", ipAddr, "ip", ipAddr)
+	reqLogger.Info("request handled", "request_id", "5fb446b6")
+
+	// bad keys with -key-style=snake_case
+	l.Info("login", "accountID", 5, "remote-addr", ipAddr)
+
+	// bad With and Log arguments
+	l.With("request_id")
+	l.Log(hclog.Info, "connected", "ip")
+
+	// bad formats
+	l.Info("too many connections", "limit", hclog.Fmt("%d of %d", numConnections))
+	l.Info("connected", "peer", hclog.Fmt("%d", ipAddr))
+	l.Info("connected", "peer", hclog.Format{ipAddr})
 }
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const hclogPath = "github.com/TerminusDeus/go-hclog"
+
+// checkFormat checks calls to hclog.Fmt and hclog.Format literals like
+// printf calls, returning whether n is one of them.
+func checkFormat(pass *analysis.Pass, n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.CallExpr:
+		if !isHCLogFunc(pass, n.Fun, "Fmt") || len(n.Args) == 0 {
+			return false
+		}
+
+		// A slice passed with ... can't be checked.
+		if n.Ellipsis != token.NoPos {
+			return true
+		}
+
+		checkPrintf(pass, "hclog.Fmt", n.Args[0], n.Args[1:], n.Rparen)
+	case *ast.CompositeLit:
+		tv := pass.TypesInfo.Types[n]
+		if tv.Type == nil || !isNamedType(tv.Type, hclogPath, "Format") || len(n.Elts) == 0 {
+			return false
+		}
+
+		for _, elt := range n.Elts {
+			if _, ok := elt.(*ast.KeyValueExpr); ok {
+				return true
+			}
+		}
+
+		checkPrintf(pass, "hclog.Format", n.Elts[0], n.Elts[1:], n.Rbrace)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// isHCLogFunc reports whether fun is the named function of the hclog
+// package.
+func isHCLogFunc(pass *analysis.Pass, fun ast.Expr, name string) bool {
+	var id *ast.Ident
+
+	switch fun := unparen(fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return false
+	}
+
+	fn, ok := pass.TypesInfo.Uses[id].(*types.Func)
+	return ok && fn.Name() == name && isPackage(fn.Pkg(), hclogPath)
+}
+
+// checkPrintf checks the format and its args. end is the position of the
+// closing parenthesis or brace, where missing args would go.
+func checkPrintf(pass *analysis.Pass, name string, format ast.Expr, args []ast.Expr, end token.Pos) {
+	tv := pass.TypesInfo.Types[format]
+	if tv.Value == nil || tv.Value.Kind() != constant.String {
+		if !isString(tv.Type) {
+			pass.ReportRangef(format, "%s format %s is not a string", name, render(pass, format))
+			return
+		}
+
+		d := analysis.Diagnostic{
+			Pos:     format.Pos(),
+			End:     format.End(),
+			Message: fmt.Sprintf("non-constant format string in %s", name),
+		}
+
+		// Without args, the string is what's meant to be logged.
+		if len(args) == 0 {
+			d.SuggestedFixes = []analysis.SuggestedFix{{
+				Message:   `Insert "%s" format string`,
+				TextEdits: []analysis.TextEdit{{Pos: format.Pos(), End: format.Pos(), NewText: []byte(`"%s", `)}},
+			}}
+		}
+
+		pass.Report(d)
+		return
+	}
+
+	verbs, err := parseVerbs(constant.StringVal(tv.Value))
+	if err != "" {
+		pass.ReportRangef(format, "%s format %s", name, err)
+		return
+	}
+
+	if len(verbs) == 0 && len(args) > 0 {
+		pass.ReportRangef(args[0], "%s call has arguments but no formatting directives", name)
+		return
+	}
+
+	maxArg := 0
+	explicit := false
+
+	for _, v := range verbs {
+		explicit = explicit || v.explicit
+
+		for _, n := range v.stars {
+			if n > maxArg {
+				maxArg = n
+			}
+			if n <= len(args) && !isInt(pass.TypesInfo.Types[args[n-1]].Type) {
+				pass.ReportRangef(args[n-1], "%s format %s uses non-int %s as argument of *", name, v.text, render(pass, args[n-1]))
+			}
+		}
+
+		if v.verb == '%' {
+			continue
+		}
+
+		if v.arg > maxArg {
+			maxArg = v.arg
+		}
+
+		if v.arg > len(args) {
+			noun := "args"
+			if len(args) == 1 {
+				noun = "arg"
+			}
+			pass.Reportf(end, "%s format %s reads arg #%d, but call has %d %s", name, v.text, v.arg, len(args), noun)
+			continue
+		}
+
+		arg := args[v.arg-1]
+		if typ := pass.TypesInfo.Types[arg].Type; !matchesVerb(v.verb, typ) {
+			pass.ReportRangef(arg, "%s format %s has arg %s of wrong type %s", name, v.text, render(pass, arg), typ)
+		}
+	}
+
+	// Args that are never read are only certain without explicit indexes.
+	if !explicit && maxArg < len(args) {
+		noun := "args"
+		if len(args) == 1 {
+			noun = "arg"
+		}
+		pass.ReportRangef(args[maxArg], "%s call needs %d args but has %d %s", name, maxArg, len(args), noun)
+	}
+}
+
+// printfVerb is a formatting directive of a format string.
+type printfVerb struct {
+	text string
+	verb rune
+
+	// arg is the number of the arg formatted, from 1, and stars the
+	// numbers of the args giving the width and precision with *.
+	arg      int
+	stars    []int
+	explicit bool
+}
+
+// parseVerbs returns the directives of format, following the rules of the
+// fmt package for explicit arg indexes, or a description of what's wrong
+// with it.
+func parseVerbs(format string) ([]printfVerb, string) {
+	var (
+		verbs  []printfVerb
+		argNum = 1
+	)
+
+	for i := 0; i < len(format); {
+		if format[i] != '%' {
+			i++
+			continue
+		}
+
+		start := i
+		i++
+
+		v := printfVerb{}
+
+		// index reads an explicit arg index like [2], if there is one.
+		index := func() string {
+			if i >= len(format) || format[i] != '[' {
+				return ""
+			}
+
+			end := strings.IndexByte(format[i:], ']')
+			if end < 0 {
+				return "has unclosed argument index"
+			}
+
+			n, err := strconv.Atoi(format[i+1 : i+end])
+			if err != nil || n < 1 {
+				return fmt.Sprintf("has invalid argument index %s", format[i:i+end+1])
+			}
+
+			argNum = n
+			v.explicit = true
+			i += end + 1
+			return ""
+		}
+
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+
+		// The width, then the precision, either of which may be read from
+		// an arg with *.
+		for part := 0; part < 2; part++ {
+			if part == 1 {
+				if i >= len(format) || format[i] != '.' {
+					break
+				}
+				i++
+			}
+
+			if err := index(); err != "" {
+				return nil, err
+			}
+
+			if i < len(format) && format[i] == '*' {
+				v.stars = append(v.stars, argNum)
+				argNum++
+				i++
+				continue
+			}
+
+			for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				i++
+			}
+		}
+
+		if err := index(); err != "" {
+			return nil, err
+		}
+
+		if i >= len(format) {
+			return nil, fmt.Sprintf("has incomplete directive %s", format[start:])
+		}
+
+		r, size := utf8.DecodeRuneInString(format[i:])
+		i += size
+
+		v.text = format[start:i]
+		v.verb = r
+
+		if r != '%' {
+			if !strings.ContainsRune("bcdeEfFgGoOpqstTUvxX", r) {
+				return nil, fmt.Sprintf("has unknown verb %c", r)
+			}
+
+			v.arg = argNum
+			argNum++
+		}
+
+		verbs = append(verbs, v)
+	}
+
+	return verbs, ""
+}
+
+// matchesVerb reports whether an arg of type typ can be formatted with
+// verb. Types whose formatting can't be told from the type alone, such as
+// interfaces and those implementing fmt.Formatter, always match.
+func matchesVerb(verb rune, typ types.Type) bool {
+	if typ == nil || verb == 'v' || verb == 'T' {
+		return true
+	}
+
+	if isFormatter(typ) {
+		return true
+	}
+
+	if strings.ContainsRune("sqxX", verb) && (implements(typ, stringerType) || implements(typ, errorType)) {
+		return true
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		info := t.Info()
+
+		switch verb {
+		case 't':
+			return info&types.IsBoolean != 0
+		case 'b':
+			return info&(types.IsInteger|types.IsFloat|types.IsComplex) != 0
+		case 'x', 'X':
+			return info&(types.IsInteger|types.IsString|types.IsFloat|types.IsComplex) != 0
+		case 'c', 'U', 'd', 'o', 'O':
+			return info&types.IsInteger != 0
+		case 'q':
+			return info&(types.IsInteger|types.IsString) != 0
+		case 'e', 'E', 'f', 'F', 'g', 'G':
+			return info&(types.IsFloat|types.IsComplex) != 0
+		case 's':
+			return info&types.IsString != 0
+		case 'p':
+			return t.Kind() == types.UnsafePointer || t.Kind() == types.UntypedNil
+		}
+	case *types.Pointer:
+		// Pointers to composite values are formatted like the values at the
+		// top level, and other pointers as numbers.
+		switch t.Elem().Underlying().(type) {
+		case *types.Struct, *types.Array, *types.Slice, *types.Map:
+			if verb != 'p' && matchesVerb(verb, t.Elem()) {
+				return true
+			}
+		}
+		return verb == 'p' || strings.ContainsRune("bdoOxX", verb)
+	case *types.Signature, *types.Chan:
+		return verb == 'p' || strings.ContainsRune("bdoOxX", verb)
+	case *types.Map:
+		return verb == 'p' || (matchesVerb(verb, t.Key()) && matchesVerb(verb, t.Elem()))
+	case *types.Slice:
+		if verb == 'p' {
+			return true
+		}
+		if b, ok := t.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte && strings.ContainsRune("sqxX", verb) {
+			return true
+		}
+		return matchesVerb(verb, t.Elem())
+	case *types.Array:
+		return verb != 'p' && matchesVerb(verb, t.Elem())
+	case *types.Struct:
+		if verb == 'p' {
+			return false
+		}
+		for i := 0; i < t.NumFields(); i++ {
+			if !matchesVerb(verb, t.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Interfaces hold values of any type.
+	return true
+}
+
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+// isFormatter reports whether typ or a pointer to it implements
+// fmt.Formatter, by having a Format(fmt.State, rune) method. The fmt package
+// may not be imported by the package being checked, so the method is
+// matched rather than the interface.
+func isFormatter(typ types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, "Format")
+
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 2 || sig.Results().Len() != 0 {
+		return false
+	}
+
+	verb, ok := sig.Params().At(1).Type().(*types.Basic)
+	return ok && verb.Kind() == types.Int32 && isNamedType(types.Unalias(sig.Params().At(0).Type()), "fmt", "State")
+}
+
+// implements reports whether typ or a pointer to it implements iface.
+func implements(typ types.Type, iface *types.Interface) bool {
+	return types.Implements(typ, iface) || types.Implements(types.NewPointer(typ), iface)
+}
+
+// isInt reports whether typ is an integer type.
+func isInt(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+
+	b, ok := typ.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsInteger != 0
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/format"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// keyStyle is a naming convention for log keys.
+type keyStyle struct {
+	name  string
+	sep   string
+	valid *regexp.Regexp
+}
+
+var keyStyles = map[string]*keyStyle{
+	"snake_case": {name: "snake_case", sep: "_", valid: regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)},
+	"kebab-case": {name: "kebab-case", sep: "-", valid: regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)},
+}
+
+// lookupKeyStyle returns the style named by the -key-style flag, or nil if
+// it's empty.
+func lookupKeyStyle(name string) (*keyStyle, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "snake":
+		name = "snake_case"
+	case "kebab":
+		name = "kebab-case"
+	}
+
+	style, ok := keyStyles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown key style %q, expected snake_case or kebab-case", name)
+	}
+
+	return style, nil
+}
+
+// matches reports whether key follows the style. Keys may be made of
+// several names joined by dots, as written for groups by the slog handler.
+func (s *keyStyle) matches(key string) bool {
+	for _, part := range strings.Split(key, ".") {
+		if !s.valid.MatchString(part) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// convert rewrites key in the style, splitting it into words at
+// separators and at changes from lower to upper case.
+func (s *keyStyle) convert(key string) string {
+	parts := strings.Split(key, ".")
+
+	for i, part := range parts {
+		var (
+			words []string
+			word  []rune
+		)
+
+		flush := func() {
+			if len(word) > 0 {
+				words = append(words, strings.ToLower(string(word)))
+				word = word[:0]
+			}
+		}
+
+		runes := []rune(part)
+		for j, r := range runes {
+			switch {
+			case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+				flush()
+			case unicode.IsUpper(r) && j > 0 && len(word) > 0 &&
+				(unicode.IsLower(runes[j-1]) || (j+1 < len(runes) && unicode.IsLower(runes[j+1]))):
+				flush()
+				word = append(word, r)
+			default:
+				word = append(word, r)
+			}
+		}
+		flush()
+
+		parts[i] = strings.Join(words, s.sep)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// keyChecker checks the keys of the log calls in a file.
+type keyChecker struct {
+	pass  *analysis.Pass
+	style *keyStyle
+
+	// derived maps variables to the call deriving the logger they hold,
+	// such as a call to With, if that's the only value assigned to them
+	// in the file. Variables assigned anything else map to nil.
+	derived map[types.Object]*ast.CallExpr
+}
+
+func newKeyChecker(pass *analysis.Pass, f *ast.File, style *keyStyle) *keyChecker {
+	c := &keyChecker{
+		pass:    pass,
+		style:   style,
+		derived: map[types.Object]*ast.CallExpr{},
+	}
+
+	assign := func(id *ast.Ident, val ast.Expr) {
+		obj := pass.TypesInfo.ObjectOf(id)
+		if obj == nil {
+			return
+		}
+
+		call := c.derivedCall(val)
+		if _, seen := c.derived[obj]; seen || call == nil {
+			c.derived[obj] = nil
+			return
+		}
+
+		c.derived[obj] = call
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				id, ok := lhs.(*ast.Ident)
+				if !ok {
+					continue
+				}
+
+				var val ast.Expr
+				if len(n.Lhs) == len(n.Rhs) {
+					val = n.Rhs[i]
+				}
+				assign(id, val)
+			}
+		case *ast.ValueSpec:
+			for i, id := range n.Names {
+				var val ast.Expr
+				if len(n.Names) == len(n.Values) {
+					val = n.Values[i]
+				}
+				assign(id, val)
+			}
+		case *ast.UnaryExpr:
+			// Variables whose address is taken may be changed anywhere.
+			if id, ok := n.X.(*ast.Ident); ok && n.Op == token.AND {
+				if obj := pass.TypesInfo.ObjectOf(id); obj != nil {
+					c.derived[obj] = nil
+				}
+			}
+		}
+
+		return true
+	})
+
+	return c
+}
+
+// derivedCall returns x as a call to With, Named or ResetNamed on a logger,
+// or nil if it's something else.
+func (c *keyChecker) derivedCall(x ast.Expr) *ast.CallExpr {
+	call, ok := unparen(x).(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || !isLogger(c.pass, fun.X) {
+		return nil
+	}
+
+	switch fun.Sel.Name {
+	case "With", "Named", "ResetNamed":
+		return call
+	default:
+		return nil
+	}
+}
+
+// impliedKeys returns the constant keys given to With for the logger x,
+// where that's evident from the file: x is a call to With, or a variable
+// only ever assigned one.
+func (c *keyChecker) impliedKeys(x ast.Expr) map[string]bool {
+	keys := map[string]bool{}
+
+	for depth := 0; depth < 16; depth++ {
+		var call *ast.CallExpr
+
+		switch x := unparen(x).(type) {
+		case *ast.Ident:
+			call = c.derived[c.pass.TypesInfo.ObjectOf(x)]
+		default:
+			call = c.derivedCall(x)
+		}
+
+		if call == nil {
+			break
+		}
+
+		fun := call.Fun.(*ast.SelectorExpr)
+		if fun.Sel.Name == "With" && call.Ellipsis == token.NoPos {
+			for i := 0; i+1 < len(call.Args); i += 2 {
+				if tv := c.pass.TypesInfo.Types[call.Args[i]]; tv.Value != nil && tv.Value.Kind() == constant.String {
+					keys[constant.StringVal(tv.Value)] = true
+				}
+			}
+		}
+
+		x = fun.X
+	}
+
+	return keys
+}
+
+// check reports problems with the keys of args, which are the key/value
+// pairs of a call starting at args[first]. recv is the logger the call is
+// made on.
+func (c *keyChecker) check(recv ast.Expr, args []ast.Expr, first int) {
+	implied := c.impliedKeys(recv)
+	seen := map[string]bool{}
+
+	for i := first; i+1 < len(args); i += 2 {
+		key := args[i]
+		tv := c.pass.TypesInfo.Types[key]
+
+		if !isString(tv.Type) {
+			d := analysis.Diagnostic{
+				Pos:     key.Pos(),
+				End:     key.End(),
+				Message: fmt.Sprintf("log key %s is not a string but %s", render(c.pass, key), tv.Type),
+			}
+
+			if implements(tv.Type, stringerType) {
+				d.SuggestedFixes = []analysis.SuggestedFix{c.callString(key)}
+			}
+
+			c.pass.Report(d)
+			continue
+		}
+
+		if tv.Value == nil {
+			c.pass.ReportRangef(key, "log key %s is not a constant", render(c.pass, key))
+			continue
+		}
+
+		name := constant.StringVal(tv.Value)
+
+		switch {
+		case seen[name]:
+			c.reportRemovable(args, i, fmt.Sprintf("duplicate log key %q", name))
+			continue
+		case implied[name]:
+			c.reportRemovable(args, i, fmt.Sprintf("log key %q is already set by With", name))
+			continue
+		}
+
+		seen[name] = true
+
+		if c.style != nil && !c.style.matches(name) {
+			d := analysis.Diagnostic{
+				Pos:     key.Pos(),
+				End:     key.End(),
+				Message: fmt.Sprintf("log key %q is not %s", name, c.style.name),
+			}
+
+			// Only literals are rewritten, since a named constant may be
+			// used elsewhere.
+			if lit, ok := unparen(key).(*ast.BasicLit); ok {
+				if fixed := c.style.convert(name); fixed != "" && c.style.matches(fixed) {
+					d.SuggestedFixes = []analysis.SuggestedFix{{
+						Message: fmt.Sprintf("Rename the key to %q", fixed),
+						TextEdits: []analysis.TextEdit{{
+							Pos:     lit.Pos(),
+							End:     lit.End(),
+							NewText: []byte(strconv.Quote(fixed)),
+						}},
+					}}
+				}
+			}
+
+			c.pass.Report(d)
+		}
+	}
+}
+
+// reportRemovable reports the key at args[i], suggesting removing its pair.
+func (c *keyChecker) reportRemovable(args []ast.Expr, i int, msg string) {
+	pos, end := args[i].Pos(), args[i+1].End()
+
+	switch {
+	case i > 0:
+		pos = args[i-1].End()
+	case len(args) > 2:
+		end = args[i+2].Pos()
+	}
+
+	c.pass.Report(analysis.Diagnostic{
+		Pos:     args[i].Pos(),
+		End:     args[i].End(),
+		Message: msg,
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message:   "Remove the key/value pair",
+			TextEdits: []analysis.TextEdit{{Pos: pos, End: end}},
+		}},
+	})
+}
+
+// callString suggests calling String on a fmt.Stringer key.
+func (c *keyChecker) callString(key ast.Expr) analysis.SuggestedFix {
+	fix := analysis.SuggestedFix{Message: "Call String on the key"}
+
+	switch unparen(key).(type) {
+	case *ast.Ident, *ast.SelectorExpr, *ast.CallExpr, *ast.IndexExpr:
+		fix.TextEdits = []analysis.TextEdit{{Pos: key.End(), End: key.End(), NewText: []byte(".String()")}}
+	default:
+		fix.TextEdits = []analysis.TextEdit{
+			{Pos: key.Pos(), End: key.Pos(), NewText: []byte("(")},
+			{Pos: key.End(), End: key.End(), NewText: []byte(").String()")},
+		}
+	}
+
+	return fix
+}
+
+// render formats x for a message.
+func render(pass *analysis.Pass, x ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, pass.Fset, x); err != nil {
+		return "argument"
+	}
+
+	return buf.String()
+}
+
+var stringerType = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "String", types.NewSignature(nil, nil, types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String])), false)),
+}, nil).Complete()
+
+// isString reports whether t is a string type.
+func isString(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+// unparen returns x without any enclosing parentheses.
+func unparen(x ast.Expr) ast.Expr {
+	for {
+		p, ok := x.(*ast.ParenExpr)
+		if !ok {
+			return x
+		}
+		x = p.X
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"go/ast"
+	"go/token"
 	"go/types"
 	"strings"
 
@@ -21,17 +22,38 @@ var Analyzer = &analysis.Analyzer{
 	Run:      run,
 }
 
+// keyStyleFlag is the naming convention log keys are checked against, if
+// any.
+var keyStyleFlag string
+
+func init() {
+	Analyzer.Flags.StringVar(&keyStyleFlag, "key-style", "", "naming convention for log keys: snake_case or kebab-case")
+}
+
 var checkHCLogFunc = map[string]bool{
 	"Trace": true,
 	"Debug": true,
 	"Info":  true,
 	"Warn":  true,
 	"Error": true,
+	"Fatal": true,
+	"Panic": true,
 }
 
 func run(pass *analysis.Pass) (interface{}, error) {
+	style, err := lookupKeyStyle(keyStyleFlag)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, f := range pass.Files {
+		keys := newKeyChecker(pass, f, style)
+
 		ast.Inspect(f, func(n ast.Node) bool {
+			if checkFormat(pass, n) {
+				return true
+			}
+
 			call, ok := n.(*ast.CallExpr)
 			if !ok {
 				return true
@@ -50,28 +72,41 @@ func run(pass *analysis.Pass) (interface{}, error) {
 				return true // the call is not on of the form x.f()
 			}
 
-			recv := pass.TypesInfo.Types[fun.X]
-			if recv.Type == nil {
-				return true
-			}
-
-			if !isNamedType(recv.Type, "github.com/TerminusDeus/go-hclog", "Logger") &&
-				!isNamedType(recv.Type, "github.com/TerminusDeus/go-hclog", "InterceptLogger") {
+			if !isLogger(pass, fun.X) {
 				return true
 			}
 
-			if _, ok := checkHCLogFunc[fun.Sel.Name]; !ok {
+			// Calls passing a slice of args can't be checked.
+			if call.Ellipsis != token.NoPos {
 				return true
 			}
 
-			// arity should be odd, with the log message being first and then followed by K/V pairs
-			if numArgs := len(call.Args); numArgs%2 != 1 {
-				pairs := numArgs / 2
-				noun := "pairs"
-				if pairs == 1 {
-					noun = "pair"
+			switch {
+			case fun.Sel.Name == "With":
+				// With takes only K/V pairs, and would log an odd one out as
+				// EXTRA_VALUE_AT_END.
+				if numArgs := len(call.Args); numArgs%2 != 0 {
+					pass.Reportf(call.Lparen, "invalid number of arguments to With (%d valid %s only)", numArgs/2, pairNoun(numArgs/2))
+				} else {
+					keys.check(fun.X, call.Args, 0)
+				}
+			case fun.Sel.Name == "Log":
+				// arity should be even, with the level and log message being
+				// first and then followed by K/V pairs
+				if numArgs := len(call.Args); numArgs%2 != 0 {
+					pairs := (numArgs - 2) / 2
+					pass.Reportf(call.Lparen, "invalid number of log arguments to Log (%d valid %s only)", pairs, pairNoun(pairs))
+				} else {
+					keys.check(fun.X, call.Args, 2)
+				}
+			case checkHCLogFunc[fun.Sel.Name]:
+				// arity should be odd, with the log message being first and then followed by K/V pairs
+				if numArgs := len(call.Args); numArgs%2 != 1 {
+					pairs := (numArgs - 1) / 2
+					pass.Reportf(call.Lparen, "invalid number of log arguments to %s (%d valid %s only)", fun.Sel.Name, pairs, pairNoun(pairs))
+				} else {
+					keys.check(fun.X, call.Args, 1)
 				}
-				pass.Reportf(call.Lparen, "invalid number of log arguments to %s (%d valid %s only)", fun.Sel.Name, pairs, noun)
 			}
 
 			return true
@@ -81,6 +116,27 @@ func run(pass *analysis.Pass) (interface{}, error) {
 	return nil, nil
 }
 
+// pairNoun returns the noun for a number of pairs.
+func pairNoun(pairs int) string {
+	if pairs == 1 {
+		return "pair"
+	}
+	return "pairs"
+}
+
+// isLogger reports whether x is an hclog.Logger, hclog.InterceptLogger or
+// hclog.FatalLogger.
+func isLogger(pass *analysis.Pass, x ast.Expr) bool {
+	recv := pass.TypesInfo.Types[x]
+	if recv.Type == nil {
+		return false
+	}
+
+	return isNamedType(recv.Type, hclogPath, "Logger") ||
+		isNamedType(recv.Type, hclogPath, "InterceptLogger") ||
+		isNamedType(recv.Type, hclogPath, "FatalLogger")
+}
+
 // isNamedType reports whether t is the named type path.name.
 func isNamedType(t types.Type, path, name string) bool {
 	n, ok := t.(*types.Named)
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// withKeyStyle sets the -key-style flag for the duration of the test.
+func withKeyStyle(t *testing.T, style string) {
+	prev := keyStyleFlag
+	keyStyleFlag = style
+	t.Cleanup(func() { keyStyleFlag = prev })
+}
+
+func TestKeys(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "keys")
+}
+
+func TestKeyStyle(t *testing.T) {
+	t.Run("snake_case", func(t *testing.T) {
+		withKeyStyle(t, "snake_case")
+		analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "snake")
+	})
+
+	t.Run("kebab-case", func(t *testing.T) {
+		withKeyStyle(t, "kebab-case")
+		analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "kebab")
+	})
+
+	t.Run("short name", func(t *testing.T) {
+		withKeyStyle(t, "kebab")
+		analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "kebab")
+	})
+}
+
+func TestFormat(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "format")
+}
+
+func TestArity(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "arity")
+}
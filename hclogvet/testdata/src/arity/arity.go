@@ -0,0 +1,29 @@
+package arity
+
+import "github.com/TerminusDeus/go-hclog"
+
+// notLogger has methods named like those of hclog.Logger.
+type notLogger struct{}
+
+func (notLogger) Info(args ...interface{}) {}
+
+func arity(logger hclog.Logger, intercept hclog.InterceptLogger, fatal hclog.FatalLogger, err error, args []interface{}) {
+	logger.Info("ok")
+	logger.Info("ok", "error", err)
+	logger.Log(hclog.Info, "ok", "error", err)
+	logger.With("error", err).Info("ok")
+	logger.Info("ok", args...)
+	notLogger{}.Info("ok", err)
+
+	logger.Info("failed", err)                          // want `invalid number of log arguments to Info \(0 valid pairs only\)`
+	logger.Error("failed", "a", 1, err)                 // want `invalid number of log arguments to Error \(1 valid pair only\)`
+	logger.Trace("failed", "a", 1, "b", 2, err)         // want `invalid number of log arguments to Trace \(2 valid pairs only\)`
+	intercept.Warn("failed", err)                       // want `invalid number of log arguments to Warn \(0 valid pairs only\)`
+	fatal.Fatal("failed", err)                          // want `invalid number of log arguments to Fatal \(0 valid pairs only\)`
+	fatal.Panic("failed", "a", 1, err)                  // want `invalid number of log arguments to Panic \(1 valid pair only\)`
+	logger.Log(hclog.Info, "failed", err)               // want `invalid number of log arguments to Log \(0 valid pairs only\)`
+	logger.Log(hclog.Info, "failed", "a", 1, err)       // want `invalid number of log arguments to Log \(1 valid pair only\)`
+	logger.Log(hclog.Info, "failed", "a", 1, "b", 2, 3) // want `invalid number of log arguments to Log \(2 valid pairs only\)`
+	logger.With(err)                                    // want `invalid number of arguments to With \(0 valid pairs only\)`
+	logger.With("a", 1, err)                            // want `invalid number of arguments to With \(1 valid pair only\)`
+}
@@ -0,0 +1,94 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/TerminusDeus/go-hclog"
+)
+
+type point struct{ x, y int }
+
+type name string
+
+func (n name) String() string { return string(n) }
+
+// formatter has its own Format method, so takes any verb.
+type formatter struct{}
+
+func (*formatter) Format(f fmt.State, verb rune) {}
+
+// notFormatter has a Format method, but not the one of fmt.Formatter.
+type notFormatter struct{ s string }
+
+func (notFormatter) Format(verb rune) {}
+
+func formats(logger hclog.Logger, s string, n int, f float64, b bool, c complex128, p *point, u unsafe.Pointer, v interface{}) {
+	// Any value can be formatted with %v and %T.
+	logger.Info("m", "a", hclog.Fmt("%v %T %+v %#v", p, p, point{}, s))
+	logger.Info("m", "a", hclog.Fmt("%d%%", n))
+
+	// Formatters and interfaces take any verb.
+	logger.Info("m", "a", hclog.Fmt("%d %x", hclog.Secret("token"), &formatter{}))
+	logger.Info("m", "a", hclog.Fmt("%d", v))
+	logger.Info("m", "a", hclog.Fmt("%d", notFormatter{})) // want `hclog.Fmt format %d has arg notFormatter\{\} of wrong type format.notFormatter`
+
+	// Stringers and errors take the string verbs.
+	logger.Info("m", "a", hclog.Fmt("%s %q %x %X", name("a"), name("b"), errors.New("c"), errors.New("d")))
+
+	logger.Info("m", "a", hclog.Fmt("%t", b))
+	logger.Info("m", "a", hclog.Fmt("%t", n)) // want `hclog.Fmt format %t has arg n of wrong type int`
+
+	logger.Info("m", "a", hclog.Fmt("%b %b %b", n, f, c))
+	logger.Info("m", "a", hclog.Fmt("%b", s)) // want `hclog.Fmt format %b has arg s of wrong type string`
+
+	logger.Info("m", "a", hclog.Fmt("%x %X %x %x", n, s, f, c))
+	logger.Info("m", "a", hclog.Fmt("%x", b)) // want `hclog.Fmt format %x has arg b of wrong type bool`
+
+	logger.Info("m", "a", hclog.Fmt("%c %U %d %o %O", n, n, n, n, n))
+	logger.Info("m", "a", hclog.Fmt("%d", s)) // want `hclog.Fmt format %d has arg s of wrong type string`
+	logger.Info("m", "a", hclog.Fmt("%c", f)) // want `hclog.Fmt format %c has arg f of wrong type float64`
+
+	logger.Info("m", "a", hclog.Fmt("%q %q", n, s))
+	logger.Info("m", "a", hclog.Fmt("%q", f)) // want `hclog.Fmt format %q has arg f of wrong type float64`
+
+	logger.Info("m", "a", hclog.Fmt("%e %E %f %F %g %G", f, f, f, f, c, c))
+	logger.Info("m", "a", hclog.Fmt("%.2f", n)) // want `hclog.Fmt format %.2f has arg n of wrong type int`
+
+	logger.Info("m", "a", hclog.Fmt("%s %s", s, []byte(s)))
+	logger.Info("m", "a", hclog.Fmt("%s", n)) // want `hclog.Fmt format %s has arg n of wrong type int`
+
+	// Pointers, functions, channels, maps and slices take %p.
+	logger.Info("m", "a", hclog.Fmt("%p %p %p %p %p %p", p, u, formats, make(chan int), map[int]int{}, []int{}))
+	logger.Info("m", "a", hclog.Fmt("%p", n))        // want `hclog.Fmt format %p has arg n of wrong type int`
+	logger.Info("m", "a", hclog.Fmt("%p", point{}))  // want `hclog.Fmt format %p has arg point\{\} of wrong type format.point`
+	logger.Info("m", "a", hclog.Fmt("%p", [2]int{})) // want `hclog.Fmt format %p has arg \[2\]int\{\} of wrong type \[2\]int`
+
+	// Composite values are checked element by element, and pointers to them
+	// like the values or as numbers.
+	logger.Info("m", "a", hclog.Fmt("%d %d %d %d %x", point{}, p, []int{1}, map[int]int{}, p))
+	logger.Info("m", "a", hclog.Fmt("%s", p))                // want `hclog.Fmt format %s has arg p of wrong type \*format.point`
+	logger.Info("m", "a", hclog.Fmt("%d", []string{}))       // want `hclog.Fmt format %d has arg \[\]string\{\} of wrong type \[\]string`
+	logger.Info("m", "a", hclog.Fmt("%d", map[string]int{})) // want `hclog.Fmt format %d has arg map\[string\]int\{\} of wrong type map\[string\]int`
+	logger.Info("m", "a", hclog.Format{"%s %d", s, n})
+	logger.Info("m", "a", hclog.Format{"%d", s}) // want `hclog.Format format %d has arg s of wrong type string`
+	logger.Info("m", "a", hclog.Format{n})       // want `hclog.Format format n is not a string`
+}
+
+func args(logger hclog.Logger, s string, n int, vals []interface{}) {
+	logger.Info("m", "a", hclog.Fmt("%[2]d %[1]s", s, n))
+	logger.Info("m", "a", hclog.Fmt("%*d %.*f", n, n, n, 1.5))
+	logger.Info("m", "a", hclog.Fmt("%s", vals...))
+
+	logger.Info("m", "a", hclog.Fmt("%d %d", n))  // want `hclog.Fmt format %d reads arg #2, but call has 1 arg`
+	logger.Info("m", "a", hclog.Fmt("%d", n, n))  // want `hclog.Fmt call needs 1 args but has 2 args`
+	logger.Info("m", "a", hclog.Fmt("done", n))   // want `hclog.Fmt call has arguments but no formatting directives`
+	logger.Info("m", "a", hclog.Fmt("%*d", s, n)) // want `hclog.Fmt format %\*d uses non-int s as argument of \*`
+	logger.Info("m", "a", hclog.Fmt("%y", n))     // want `hclog.Fmt format has unknown verb y`
+	logger.Info("m", "a", hclog.Fmt("%", n))      // want `hclog.Fmt format has incomplete directive %`
+	logger.Info("m", "a", hclog.Fmt("%[0]d", n))  // want `hclog.Fmt format has invalid argument index \[0\]`
+	logger.Info("m", "a", hclog.Fmt("%[1d", n))   // want `hclog.Fmt format has unclosed argument index`
+	logger.Info("m", "a", hclog.Fmt(s))           // want `non-constant format string in hclog.Fmt`
+	logger.Info("m", "a", hclog.Fmt(s, n))        // want `non-constant format string in hclog.Fmt`
+}
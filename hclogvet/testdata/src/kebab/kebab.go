@@ -0,0 +1,11 @@
+package kebab
+
+import "github.com/TerminusDeus/go-hclog"
+
+func kebab(logger hclog.Logger, id string) {
+	logger.Info("ok", "account-id", id)
+
+	logger.Info("m", "accountID", id)  // want `log key "accountID" is not kebab-case`
+	logger.Info("m", "HTTPServer", id) // want `log key "HTTPServer" is not kebab-case`
+	logger.Info("m", "request_id", id) // want `log key "request_id" is not kebab-case`
+}
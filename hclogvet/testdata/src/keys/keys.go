@@ -0,0 +1,27 @@
+package keys
+
+import "github.com/TerminusDeus/go-hclog"
+
+type stringer struct{}
+
+func (stringer) String() string { return "key" }
+
+func keys(logger hclog.Logger, id string, key string, s stringer) {
+	logger.Info("ok", "a", 1, "b", 2)
+
+	logger.Info("m", s, 1)          // want `log key s is not a string but keys.stringer`
+	logger.Info("m", stringer{}, 1) // want `log key stringer\{\} is not a string but keys.stringer`
+	logger.Info("m", 1, 2)          // want `log key 1 is not a string but int`
+	logger.Info("m", key, 1)        // want `log key key is not a constant`
+
+	logger.Info("m", "a", 1, "a", 2, "c", 3) // want `duplicate log key "a"`
+	logger.Info("m", "a", 1, "c", 2, "a", 3) // want `duplicate log key "a"`
+
+	reqLogger := logger.With("request_id", id)
+	reqLogger.Info("m", "request_id", id, "b", 2)      // want `log key "request_id" is already set by With`
+	reqLogger.With("request_id", id, "b", 2).Info("m") // want `log key "request_id" is already set by With`
+	reqLogger.With("b", 2, "request_id", id).Info("m") // want `log key "request_id" is already set by With`
+	reqLogger.With("request_id", id).Info("m")         // want `log key "request_id" is already set by With`
+
+	logger.With("a", 1).Named("sub").Warn("m", "a", 2) // want `log key "a" is already set by With`
+}
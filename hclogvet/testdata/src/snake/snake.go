@@ -0,0 +1,19 @@
+package snake
+
+import "github.com/TerminusDeus/go-hclog"
+
+const userKey = "userName"
+
+func snake(logger hclog.Logger, id string) {
+	logger.Info("ok", "account_id", id, "http.status_code", 200)
+
+	logger.Info("m", "accountID", id)         // want `log key "accountID" is not snake_case`
+	logger.Info("m", "HTTPServer", id)        // want `log key "HTTPServer" is not snake_case`
+	logger.Info("m", "request-id", id)        // want `log key "request-id" is not snake_case`
+	logger.Info("m", "http.StatusCode", 200)  // want `log key "http.StatusCode" is not snake_case`
+	logger.With("remoteAddr", id).Info("m")   // want `log key "remoteAddr" is not snake_case`
+	logger.Log(hclog.Info, "m", "userID", id) // want `log key "userID" is not snake_case`
+
+	// Named constants may be used elsewhere, so aren't renamed.
+	logger.Info("m", userKey, id) // want `log key "userName" is not snake_case`
+}
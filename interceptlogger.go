@@ -8,6 +8,9 @@ import (
 )
 
 var _ Logger = &interceptLogger{}
+var _ Flushable = &interceptLogger{}
+var _ FatalLogger = &interceptLogger{}
+var _ LevelGetter = &interceptLogger{}
 
 type interceptLogger struct {
 	Logger
@@ -49,6 +52,8 @@ func (i *interceptLogger) log(level Level, msg string, args ...interface{}) {
 		return
 	}
 
+	msg, args = i.redact(msg, args)
+
 	i.mu.Lock()
 	defer i.mu.Unlock()
 	for s := range i.Sinks {
@@ -56,6 +61,38 @@ func (i *interceptLogger) log(level Level, msg string, args ...interface{}) {
 	}
 }
 
+// logEntry implements entryLogger so that entries with an already resolved
+// caller, such as those from a log/slog Handler, reach the sinks as well.
+func (i *interceptLogger) logEntry(name string, level Level, msg string, pc uintptr, args ...interface{}) {
+	if el, ok := i.Logger.(entryLogger); ok {
+		el.logEntry(name, level, msg, pc, args...)
+	} else {
+		i.Logger.Log(level, msg, args...)
+	}
+	if atomic.LoadInt32(i.sinkCount) == 0 {
+		return
+	}
+
+	msg, args = i.redact(msg, args)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for s := range i.Sinks {
+		s.Accept(name, level, msg, i.retrieveImplied(args...)...)
+	}
+}
+
+// redact runs the wrapped logger's Redactor over msg and args so that sinks
+// never see what the logger itself would have masked.
+func (i *interceptLogger) redact(msg string, args []interface{}) (string, []interface{}) {
+	l, ok := i.Logger.(*intLogger)
+	if !ok || l.redactor == nil {
+		return msg, args
+	}
+
+	return l.redactor.RedactString(msg), l.redactor.redactArgs(args)
+}
+
 // Emit the message and args at TRACE level to log and sinks
 func (i *interceptLogger) Trace(msg string, args ...interface{}) {
 	i.log(Trace, msg, args...)
@@ -81,6 +118,80 @@ func (i *interceptLogger) Error(msg string, args ...interface{}) {
 	i.log(Error, msg, args...)
 }
 
+// Emit the message and args at FATAL level to log and sinks, then flush the
+// output and exit
+func (i *interceptLogger) Fatal(msg string, args ...interface{}) {
+	i.log(Fatal, msg, args...)
+	i.flushOutput()
+	exitFunc(1)
+}
+
+// Emit the message and args at PANIC level to log and sinks, then flush the
+// output and panic
+func (i *interceptLogger) Panic(msg string, args ...interface{}) {
+	i.log(Panic, msg, args...)
+	i.flushOutput()
+	panic(msg)
+}
+
+// Returns the level of the logger, like intLogger.GetLevel
+func (i *interceptLogger) GetLevel() Level {
+	return i.Logger.(LevelGetter).GetLevel()
+}
+
+// isEnabled indicates if the logger would emit entries at level.
+func (i *interceptLogger) isEnabled(level Level) bool {
+	return IsLevelEnabled(i.Logger, level)
+}
+
+// overriddenLevel returns the level set for the logger in its
+// LevelOverrides, if there is one.
+func (i *interceptLogger) overriddenLevel() (Level, bool) {
+	if o, ok := i.Logger.(levelOverrider); ok {
+		return o.overriddenLevel()
+	}
+
+	return NoLevel, false
+}
+
+// flushOutput flushes the output of the logger and of any sinks that support
+// it.
+func (i *interceptLogger) flushOutput() {
+	if f, ok := i.Logger.(outputFlusher); ok {
+		f.flushOutput()
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for s := range i.Sinks {
+		if f, ok := s.(outputFlusher); ok {
+			f.flushOutput()
+		}
+	}
+}
+
+// Flush flushes the logger and any sinks that are Flushable, returning the
+// first error.
+func (i *interceptLogger) Flush() error {
+	var err error
+
+	if f, ok := i.Logger.(Flushable); ok {
+		err = f.Flush()
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for s := range i.Sinks {
+		if f, ok := s.(Flushable); ok {
+			if serr := f.Flush(); err == nil {
+				err = serr
+			}
+		}
+	}
+
+	return err
+}
+
 func (i *interceptLogger) retrieveImplied(args ...interface{}) []interface{} {
 	top := i.Logger.ImpliedArgs()
 
@@ -35,22 +35,6 @@ const TimeFormatJSON = "2006-01-02T15:04:05.000000Z07:00"
 const errJsonUnsupportedTypeMsg = "logging contained values that don't serialize to json"
 
 var (
-	_levelToBracket = map[Level]string{
-		Debug: "[DEBUG]",
-		Trace: "[TRACE]",
-		Info:  "[INFO] ",
-		Warn:  "[WARN] ",
-		Error: "[ERROR]",
-	}
-
-	_levelToColor = map[Level]*color.Color{
-		Debug: color.New(color.FgHiWhite),
-		Trace: color.New(color.FgHiGreen),
-		Info:  color.New(color.FgHiBlue),
-		Warn:  color.New(color.FgHiYellow),
-		Error: color.New(color.FgHiRed),
-	}
-
 	faintBoldColor                 = color.New(color.Faint, color.Bold)
 	faintColor                     = color.New(color.Faint)
 	faintMultiLinePrefix           = faintColor.Sprint("  | ")
@@ -61,15 +45,21 @@ var (
 // Make sure that intLogger is a Logger
 var _ Logger = &intLogger{}
 
+// Make sure that intLogger is Flushable
+var _ Flushable = &intLogger{}
+
+// Make sure that intLogger is a FatalLogger
+var _ FatalLogger = &intLogger{}
+
+// Make sure that intLogger is a LevelGetter
+var _ LevelGetter = &intLogger{}
+
 // intLogger is an internal logger implementation. Internal in that it is
 // defined entirely by this package.
 type intLogger struct {
-	json         bool
 	callerOffset int
 	name         string
-	timeFormat   string
 	timeFn       TimeFunction
-	disableTime  bool
 
 	// This is an interface so that it's shared by any derived loggers, since
 	// those derived loggers share the bufio.Writer as well.
@@ -77,15 +67,36 @@ type intLogger struct {
 	writer *writer
 	level  *int32
 
+	// encoder formats the entries. If the built-in encoder for the plain
+	// format is used, it is kept in sync with the colors below.
+	encoder     Encoder
 	headerColor ColorOption
 	fieldColor  ColorOption
 
 	implied []interface{}
 
-	exclude func(level Level, msg string, args ...interface{}) bool
+	exclude      func(level Level, msg string, args ...interface{}) bool
+	excludeNamed func(name string, level Level, msg string, args ...interface{}) bool
 
 	// create subloggers with their own level setting
 	independentLevels bool
+
+	// overrides, if set, can override level based on the name of the
+	// logger. The cache is replaced whenever the name changes.
+	overrides     *LevelOverrides
+	overrideCache *levelOverrideCache
+
+	// sampler, if set, drops repeated entries. It's shared by any derived
+	// loggers.
+	sampler *Sampler
+
+	// dedup, if set, collapses consecutive identical entries. Like the
+	// mutex, it's shared by any derived loggers.
+	dedup *deduper
+
+	// redactor, if set, masks secrets in messages and args. Implied args
+	// are redacted once, when they're given to With.
+	redactor *Redactor
 }
 
 // New returns a configured logger.
@@ -140,16 +151,18 @@ func newLogger(opts *LoggerOptions) *intLogger {
 	}
 
 	l := &intLogger{
-		json:              opts.JSONFormat,
 		name:              opts.Name,
-		timeFormat:        TimeFormat,
 		timeFn:            time.Now,
-		disableTime:       opts.DisableTime,
 		mutex:             mutex,
 		writer:            newWriter(output, primaryColor),
 		level:             new(int32),
 		exclude:           opts.Exclude,
+		excludeNamed:      opts.ExcludeNamed,
 		independentLevels: opts.IndependentLevels,
+		overrides:         opts.LevelOverrides,
+		overrideCache:     new(levelOverrideCache),
+		sampler:           opts.Sampler,
+		redactor:          opts.Redactor,
 		headerColor:       headerColor,
 		fieldColor:        fieldColor,
 	}
@@ -157,18 +170,21 @@ func newLogger(opts *LoggerOptions) *intLogger {
 		l.callerOffset = offsetIntLogger + opts.AdditionalLocationOffset
 	}
 
-	if l.json {
-		l.timeFormat = TimeFormatJSON
-	}
 	if opts.TimeFn != nil {
 		l.timeFn = opts.TimeFn
 	}
-	if opts.TimeFormat != "" {
-		l.timeFormat = opts.TimeFormat
+
+	if opts.DeduplicateInterval > 0 {
+		l.dedup = newDeduper(opts.DeduplicateInterval, mutex)
 	}
 
 	l.setColorization(opts)
 
+	l.encoder = opts.Encoder
+	if l.encoder == nil {
+		l.encoder = newBuiltinEncoder(opts, l.headerColor, l.fieldColor)
+	}
+
 	atomic.StoreInt32(l.level, int32(level))
 
 	return l
@@ -176,17 +192,136 @@ func newLogger(opts *LoggerOptions) *intLogger {
 
 // offsetIntLogger is the stack frame offset in the call stack for the caller to
 // one of the Warn, Info, Log, etc methods.
-const offsetIntLogger = 3
+const offsetIntLogger = 2
+
+// newBuiltinEncoder returns the Encoder for the format selected in opts.
+func newBuiltinEncoder(opts *LoggerOptions, headerColor, fieldColor ColorOption) Encoder {
+	format := opts.OutputFormat
+	if opts.JSONFormat {
+		format = FormatJSON
+	}
+
+	timeFormat := TimeFormat
+	if format != FormatPlain {
+		timeFormat = TimeFormatJSON
+	}
+	if opts.TimeFormat != "" {
+		timeFormat = opts.TimeFormat
+	}
+
+	switch format {
+	case FormatJSON:
+		return &jsonEncoder{
+			timeFormat:  timeFormat,
+			disableTime: opts.DisableTime,
+		}
+	case FormatLogfmt:
+		return &logfmtEncoder{
+			timeFormat:  timeFormat,
+			disableTime: opts.DisableTime,
+		}
+	default:
+		return &plainEncoder{
+			timeFormat:  timeFormat,
+			disableTime: opts.DisableTime,
+			headerColor: headerColor,
+			fieldColor:  fieldColor,
+		}
+	}
+}
 
 // Log a message and a set of key/value pairs if the given level is at
 // or more severe that the threshold configured in the Logger.
 func (l *intLogger) log(name string, level Level, msg string, args ...interface{}) {
-	if level < Level(atomic.LoadInt32(l.level)) {
+	if !l.isEnabledFor(name, level) {
+		return
+	}
+
+	var caller *runtime.Frame
+	if l.callerOffset > 0 {
+		if pc, file, line, ok := runtime.Caller(l.callerOffset); ok {
+			caller = &runtime.Frame{PC: pc, File: file, Line: line}
+		}
+	}
+
+	l.write(name, level, msg, caller, args...)
+}
+
+// entryLogger is implemented by loggers that can emit an entry whose caller
+// has already been resolved elsewhere, such as a log/slog Record. pc is the
+// program counter of the call site, or 0 if unknown.
+type entryLogger interface {
+	logEntry(name string, level Level, msg string, pc uintptr, args ...interface{})
+}
+
+// logEntry is like log, but uses pc for the location information instead of
+// walking the stack.
+func (l *intLogger) logEntry(name string, level Level, msg string, pc uintptr, args ...interface{}) {
+	if !l.isEnabledFor(name, level) {
 		return
 	}
 
+	var caller *runtime.Frame
+	if l.callerOffset > 0 && pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if frame.File != "" {
+			caller = &frame
+		}
+	}
+
+	l.write(name, level, msg, caller, args...)
+}
+
+// effectiveLevel returns the level for entries logged under name, taking any
+// level overrides for the name into account. The name is usually that of the
+// logger, but differs for entries passed to a SinkAdapter.
+func (l *intLogger) effectiveLevel(name string) Level {
+	if level, ok := l.overriddenLevelFor(name); ok {
+		return level
+	}
+
+	return Level(atomic.LoadInt32(l.level))
+}
+
+// overriddenLevel returns the level set for the name of the logger in its
+// LevelOverrides, if there is one.
+func (l *intLogger) overriddenLevel() (Level, bool) {
+	return l.overriddenLevelFor(l.name)
+}
+
+// overriddenLevelFor returns the level set for name in the LevelOverrides of
+// the logger, if there is one.
+func (l *intLogger) overriddenLevelFor(name string) (Level, bool) {
+	if l.overrides == nil {
+		return NoLevel, false
+	}
+
+	return l.overrideCache.lookup(l.overrides, name)
+}
+
+// isEnabled indicates if the logger would emit entries at level.
+func (l *intLogger) isEnabled(level Level) bool {
+	return l.isEnabledFor(l.name, level)
+}
+
+// isEnabledFor indicates if the logger would emit entries at level logged
+// under name.
+func (l *intLogger) isEnabledFor(name string, level Level) bool {
+	return levelSeverity(level) >= levelSeverity(l.effectiveLevel(name))
+}
+
+// write filters and samples the entry, then formats it and sends it to the
+// output.
+func (l *intLogger) write(name string, level Level, msg string, caller *runtime.Frame, args ...interface{}) {
 	t := l.timeFn()
 
+	// Redacting doesn't need the lock, so is done first to keep the time
+	// it's held short.
+	if l.redactor != nil {
+		msg = l.redactor.RedactString(msg)
+		args = l.redactor.redactArgs(args)
+	}
+
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
@@ -194,13 +329,47 @@ func (l *intLogger) log(name string, level Level, msg string, args ...interface{
 		return
 	}
 
-	if l.json {
-		l.logJSON(t, name, level, msg, args...)
-	} else {
-		l.logPlain(t, name, level, msg, args...)
+	if l.excludeNamed != nil {
+		all := append(l.implied[:len(l.implied):len(l.implied)], args...)
+		if l.excludeNamed(name, level, msg, all...) {
+			return
+		}
+	}
+
+	// Sampling comes after the filters, so that excluded entries don't use
+	// up the sample.
+	if l.sampler != nil && !l.sampler.allow(l, name, level, msg) {
+		return
+	}
+
+	l.encode(t, name, level, msg, caller, args)
+}
+
+// writeSummary writes an entry summarizing others, such as those dropped by
+// the sampler, which is neither filtered nor sampled again.
+func (l *intLogger) writeSummary(name string, level Level, msg string, args ...interface{}) {
+	t := l.timeFn()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.encode(t, name, level, msg, nil, args)
+}
+
+// encode formats the entry and sends it to the output. The lock must be
+// held.
+func (l *intLogger) encode(t time.Time, name string, level Level, msg string, caller *runtime.Frame, args []interface{}) {
+	if l.dedup != nil && l.dedup.repeat(l, name, level, msg, caller, args) {
+		return
 	}
 
-	l.writer.Flush(level)
+	if err := l.encoder.Encode(&l.writer.b, t, name, level, msg, caller, l.implied, args); err != nil {
+		// Don't write out a partially encoded entry
+		l.writer.b.Reset()
+		return
+	}
+
+	l.writer.Flush(name, level)
 }
 
 // Cleanup a path by returning the last 2 segments of the path only.
@@ -250,8 +419,15 @@ func needsQuoting(str string) bool {
 	return false
 }
 
-// logPlain is the non-JSON logging format function which writes directly
-// to the underlying writer the logger was initialized with.
+// plainEncoder is the built-in Encoder for FormatPlain.
+type plainEncoder struct {
+	timeFormat  string
+	disableTime bool
+	headerColor ColorOption
+	fieldColor  ColorOption
+}
+
+// Encode writes the entry in the non-JSON logging format.
 //
 // If the logger was initialized with a color function, it also handles
 // applying the color to the log message.
@@ -261,48 +437,44 @@ func needsQuoting(str string) bool {
 //  2. Color the whole log line, based on the level.
 //  3. Color only the header (level) part of the log line.
 //  4. Color both the header and fields of the log line.
-func (l *intLogger) logPlain(t time.Time, name string, level Level, msg string, args ...interface{}) {
-
-	if !l.disableTime {
-		l.writer.WriteString(t.Format(l.timeFormat))
-		l.writer.WriteByte(' ')
+func (e *plainEncoder) Encode(buf *bytes.Buffer, t time.Time, name string, level Level, msg string, caller *runtime.Frame, implied, args []interface{}) error {
+	if !e.disableTime {
+		buf.WriteString(t.Format(e.timeFormat))
+		buf.WriteByte(' ')
 	}
 
-	s, ok := _levelToBracket[level]
+	s, ok := levelBracket(level)
 	if ok {
-		if l.headerColor != ColorOff {
-			color := _levelToColor[level]
-			color.Fprint(l.writer, s)
+		if color := levelColor(level); color != nil && e.headerColor != ColorOff {
+			color.Fprint(buf, s)
 		} else {
-			l.writer.WriteString(s)
+			buf.WriteString(s)
 		}
 	} else {
-		l.writer.WriteString("[?????]")
+		buf.WriteString("[?????]")
 	}
 
-	if l.callerOffset > 0 {
-		if _, file, line, ok := runtime.Caller(l.callerOffset); ok {
-			l.writer.WriteByte(' ')
-			l.writer.WriteString(trimCallerPath(file))
-			l.writer.WriteByte(':')
-			l.writer.WriteString(strconv.Itoa(line))
-			l.writer.WriteByte(':')
-		}
+	if caller != nil {
+		buf.WriteByte(' ')
+		buf.WriteString(trimCallerPath(caller.File))
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(caller.Line))
+		buf.WriteByte(':')
 	}
 
-	l.writer.WriteByte(' ')
+	buf.WriteByte(' ')
 
 	if name != "" {
-		l.writer.WriteString(name)
+		buf.WriteString(name)
 		if msg != "" {
-			l.writer.WriteString(": ")
-			l.writer.WriteString(msg)
+			buf.WriteString(": ")
+			buf.WriteString(msg)
 		}
 	} else if msg != "" {
-		l.writer.WriteString(msg)
+		buf.WriteString(msg)
 	}
 
-	args = append(l.implied, args...)
+	args = append(implied, args...)
 
 	var stacktrace CapturedStacktrace
 
@@ -318,80 +490,22 @@ func (l *intLogger) logPlain(t time.Time, name string, level Level, msg string,
 			}
 		}
 
-		l.writer.WriteByte(':')
+		buf.WriteByte(':')
 
 		// Handle the field arguments, which come in pairs (key=val).
-	FOR:
 		for i := 0; i < len(args); i = i + 2 {
-			var (
-				key string
-				val string
-				raw bool
-			)
-
-			// Convert the field value to a string.
-			switch st := args[i+1].(type) {
-			case string:
-				val = st
-				if st == "" {
-					val = `""`
-					raw = true
-				}
-			case int:
-				val = strconv.FormatInt(int64(st), 10)
-			case int64:
-				val = strconv.FormatInt(int64(st), 10)
-			case int32:
-				val = strconv.FormatInt(int64(st), 10)
-			case int16:
-				val = strconv.FormatInt(int64(st), 10)
-			case int8:
-				val = strconv.FormatInt(int64(st), 10)
-			case uint:
-				val = strconv.FormatUint(uint64(st), 10)
-			case uint64:
-				val = strconv.FormatUint(uint64(st), 10)
-			case uint32:
-				val = strconv.FormatUint(uint64(st), 10)
-			case uint16:
-				val = strconv.FormatUint(uint64(st), 10)
-			case uint8:
-				val = strconv.FormatUint(uint64(st), 10)
-			case Hex:
-				val = "0x" + strconv.FormatUint(uint64(st), 16)
-			case Octal:
-				val = "0" + strconv.FormatUint(uint64(st), 8)
-			case Binary:
-				val = "0b" + strconv.FormatUint(uint64(st), 2)
-			case CapturedStacktrace:
+			if st, ok := args[i+1].(CapturedStacktrace); ok {
 				stacktrace = st
-				continue FOR
-			case Format:
-				val = fmt.Sprintf(st[0].(string), st[1:]...)
-			case Quote:
-				raw = true
-				val = strconv.Quote(string(st))
-			default:
-				v := reflect.ValueOf(st)
-				if v.Kind() == reflect.Slice {
-					val = l.renderSlice(v)
-					raw = true
-				} else {
-					val = fmt.Sprintf("%v", st)
-				}
+				continue
 			}
 
-			// Convert the field key to a string.
-			switch st := args[i].(type) {
-			case string:
-				key = st
-			default:
-				key = fmt.Sprintf("%s", st)
-			}
+			// Convert the field value and key to strings.
+			val, raw := renderValue(args[i+1])
+			key := renderKey(args[i])
 
 			// Optionally apply the ANSI "faint" and "bold"
 			// SGR values to the key.
-			if l.fieldColor != ColorOff {
+			if e.fieldColor != ColorOff {
 				key = faintBoldColor.Sprint(key)
 			}
 
@@ -403,49 +517,118 @@ func (l *intLogger) logPlain(t time.Time, name string, level Level, msg string,
 			// in the value string are "normal", like if they
 			// contain ANSI escape sequences.
 			if strings.Contains(val, "\n") {
-				l.writer.WriteString("\n  ")
-				l.writer.WriteString(key)
-				if l.fieldColor != ColorOff {
-					l.writer.WriteString(faintFieldSeparatorWithNewLine)
-					writeIndent(l.writer, val, faintMultiLinePrefix)
+				buf.WriteString("\n  ")
+				buf.WriteString(key)
+				if e.fieldColor != ColorOff {
+					buf.WriteString(faintFieldSeparatorWithNewLine)
+					writeIndent(buf, val, faintMultiLinePrefix)
 				} else {
-					l.writer.WriteString("=\n")
-					writeIndent(l.writer, val, "  | ")
+					buf.WriteString("=\n")
+					writeIndent(buf, val, "  | ")
 				}
-				l.writer.WriteString("  ")
+				buf.WriteString("  ")
 			} else if !raw && needsQuoting(val) {
-				l.writer.WriteByte(' ')
-				l.writer.WriteString(key)
-				if l.fieldColor != ColorOff {
-					l.writer.WriteString(faintFieldSeparator)
+				buf.WriteByte(' ')
+				buf.WriteString(key)
+				if e.fieldColor != ColorOff {
+					buf.WriteString(faintFieldSeparator)
 				} else {
-					l.writer.WriteByte('=')
+					buf.WriteByte('=')
 				}
-				l.writer.WriteByte('"')
-				writeEscapedForOutput(l.writer, val, true)
-				l.writer.WriteByte('"')
+				buf.WriteByte('"')
+				writeEscapedForOutput(buf, val, true)
+				buf.WriteByte('"')
 			} else {
-				l.writer.WriteByte(' ')
-				l.writer.WriteString(key)
-				if l.fieldColor != ColorOff {
-					l.writer.WriteString(faintFieldSeparator)
+				buf.WriteByte(' ')
+				buf.WriteString(key)
+				if e.fieldColor != ColorOff {
+					buf.WriteString(faintFieldSeparator)
 				} else {
-					l.writer.WriteByte('=')
+					buf.WriteByte('=')
 				}
-				l.writer.WriteString(val)
+				buf.WriteString(val)
 			}
 		}
 	}
 
-	l.writer.WriteString("\n")
+	buf.WriteString("\n")
 
 	if stacktrace != "" {
-		l.writer.WriteString(string(stacktrace))
-		l.writer.WriteString("\n")
+		buf.WriteString(string(stacktrace))
+		buf.WriteString("\n")
+	}
+
+	return nil
+}
+
+// renderValue converts a field value to the string used for it in the text
+// formats. raw indicates that the string is already in its final form, such
+// as a quoted string or a rendered slice, and must not be quoted again.
+func renderValue(v interface{}) (val string, raw bool) {
+	switch st := v.(type) {
+	case string:
+		val = st
+		if st == "" {
+			val = `""`
+			raw = true
+		}
+	case int:
+		val = strconv.FormatInt(int64(st), 10)
+	case int64:
+		val = strconv.FormatInt(int64(st), 10)
+	case int32:
+		val = strconv.FormatInt(int64(st), 10)
+	case int16:
+		val = strconv.FormatInt(int64(st), 10)
+	case int8:
+		val = strconv.FormatInt(int64(st), 10)
+	case uint:
+		val = strconv.FormatUint(uint64(st), 10)
+	case uint64:
+		val = strconv.FormatUint(uint64(st), 10)
+	case uint32:
+		val = strconv.FormatUint(uint64(st), 10)
+	case uint16:
+		val = strconv.FormatUint(uint64(st), 10)
+	case uint8:
+		val = strconv.FormatUint(uint64(st), 10)
+	case Hex:
+		val = "0x" + strconv.FormatUint(uint64(st), 16)
+	case Octal:
+		val = "0" + strconv.FormatUint(uint64(st), 8)
+	case Binary:
+		val = "0b" + strconv.FormatUint(uint64(st), 2)
+	case CapturedStacktrace:
+		val = string(st)
+	case Format:
+		val = fmt.Sprintf(st[0].(string), st[1:]...)
+	case Quote:
+		raw = true
+		val = strconv.Quote(string(st))
+	default:
+		v := reflect.ValueOf(st)
+		if v.Kind() == reflect.Slice {
+			val = renderSlice(v)
+			raw = true
+		} else {
+			val = fmt.Sprintf("%v", st)
+		}
+	}
+
+	return val, raw
+}
+
+// renderKey converts a field key to a string.
+func renderKey(k interface{}) string {
+	switch st := k.(type) {
+	case string:
+		return st
+	default:
+		return fmt.Sprintf("%s", st)
 	}
 }
 
-func writeIndent(w *writer, str string, indent string) {
+func writeIndent(w *bytes.Buffer, str string, indent string) {
 	for {
 		nl := strings.IndexByte(str, "\n"[0])
 		if nl == -1 {
@@ -543,7 +726,7 @@ func writeEscapedForOutput(w io.Writer, str string, escapeQuotes bool) {
 	w.Write(bb.Bytes())
 }
 
-func (l *intLogger) renderSlice(v reflect.Value) string {
+func renderSlice(v reflect.Value) string {
 	var buf bytes.Buffer
 
 	buf.WriteRune('[')
@@ -579,10 +762,16 @@ func (l *intLogger) renderSlice(v reflect.Value) string {
 	return buf.String()
 }
 
-// JSON logging function
-func (l *intLogger) logJSON(t time.Time, name string, level Level, msg string, args ...interface{}) {
-	vals := l.jsonMapEntry(t, name, level, msg)
-	args = append(l.implied, args...)
+// jsonEncoder is the built-in Encoder for FormatJSON.
+type jsonEncoder struct {
+	timeFormat  string
+	disableTime bool
+}
+
+// Encode writes the entry as a JSON object.
+func (e *jsonEncoder) Encode(buf *bytes.Buffer, t time.Time, name string, level Level, msg string, caller *runtime.Frame, implied, args []interface{}) error {
+	vals := e.jsonMapEntry(t, name, level, msg, caller)
+	args = append(implied, args...)
 
 	if args != nil && len(args) > 0 {
 		if len(args)%2 != 0 {
@@ -597,20 +786,7 @@ func (l *intLogger) logJSON(t time.Time, name string, level Level, msg string, a
 		}
 
 		for i := 0; i < len(args); i = i + 2 {
-			val := args[i+1]
-			switch sv := val.(type) {
-			case error:
-				// Check if val is of type error. If error type doesn't
-				// implement json.Marshaler or encoding.TextMarshaler
-				// then set val to err.Error() so that it gets marshaled
-				switch sv.(type) {
-				case json.Marshaler, encoding.TextMarshaler:
-				default:
-					val = sv.Error()
-				}
-			case Format:
-				val = fmt.Sprintf(sv[0].(string), sv[1:]...)
-			}
+			val := jsonValue(args[i+1])
 
 			var key string
 
@@ -624,51 +800,55 @@ func (l *intLogger) logJSON(t time.Time, name string, level Level, msg string, a
 		}
 	}
 
-	err := json.NewEncoder(l.writer).Encode(vals)
+	err := json.NewEncoder(buf).Encode(vals)
 	if err != nil {
 		if _, ok := err.(*json.UnsupportedTypeError); ok {
-			plainVal := l.jsonMapEntry(t, name, level, msg)
+			plainVal := e.jsonMapEntry(t, name, level, msg, caller)
 			plainVal["warn"] = errJsonUnsupportedTypeMsg
 
-			json.NewEncoder(l.writer).Encode(plainVal)
+			return json.NewEncoder(buf).Encode(plainVal)
 		}
 	}
+
+	return err
+}
+
+// jsonValue converts val into the value that is marshaled for it in JSON
+// output.
+func jsonValue(val interface{}) interface{} {
+	switch sv := val.(type) {
+	case error:
+		// Check if val is of type error. If error type doesn't
+		// implement json.Marshaler or encoding.TextMarshaler
+		// then set val to err.Error() so that it gets marshaled
+		switch sv.(type) {
+		case json.Marshaler, encoding.TextMarshaler:
+		default:
+			return sv.Error()
+		}
+	case Format:
+		return fmt.Sprintf(sv[0].(string), sv[1:]...)
+	}
+
+	return val
 }
 
-func (l intLogger) jsonMapEntry(t time.Time, name string, level Level, msg string) map[string]interface{} {
+func (e *jsonEncoder) jsonMapEntry(t time.Time, name string, level Level, msg string, caller *runtime.Frame) map[string]interface{} {
 	vals := map[string]interface{}{
 		"message": msg,
 	}
-	if !l.disableTime {
-		vals["timestamp"] = t.Format(l.timeFormat)
-	}
-
-	var levelStr string
-	switch level {
-	case Error:
-		levelStr = "error"
-	case Warn:
-		levelStr = "warn"
-	case Info:
-		levelStr = "info"
-	case Debug:
-		levelStr = "debug"
-	case Trace:
-		levelStr = "trace"
-	default:
-		levelStr = "all"
+	if !e.disableTime {
+		vals["timestamp"] = t.Format(e.timeFormat)
 	}
 
-	vals["level"] = levelStr
+	vals["level"] = levelJSONName(level)
 
 	if name != "" {
 		vals["module"] = name
 	}
 
-	if l.callerOffset > 0 {
-		if _, file, line, ok := runtime.Caller(l.callerOffset + 1); ok {
-			vals["caller"] = fmt.Sprintf("%s:%d", file, line)
-		}
+	if caller != nil {
+		vals["caller"] = fmt.Sprintf("%s:%d", caller.File, caller.Line)
 	}
 	return vals
 }
@@ -703,29 +883,71 @@ func (l *intLogger) Error(msg string, args ...interface{}) {
 	l.log(l.Name(), Error, msg, args...)
 }
 
+// Emit the message and args at FATAL level, then flush the output and exit
+func (l *intLogger) Fatal(msg string, args ...interface{}) {
+	l.log(l.Name(), Fatal, msg, args...)
+	l.flushOutput()
+	exitFunc(1)
+}
+
+// Emit the message and args at PANIC level, then flush the output and panic
+func (l *intLogger) Panic(msg string, args ...interface{}) {
+	l.log(l.Name(), Panic, msg, args...)
+	l.flushOutput()
+	panic(msg)
+}
+
+// outputFlusher is implemented by loggers that can flush their output before
+// the program exits.
+type outputFlusher interface {
+	flushOutput()
+}
+
+// flushOutput flushes the output if it is Flushable, such as an AsyncWriter.
+func (l *intLogger) flushOutput() {
+	l.Flush()
+}
+
+// Flush writes out any pending summary of repeated entries, then flushes the
+// output if it is Flushable.
+func (l *intLogger) Flush() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.dedup != nil {
+		l.dedup.flush()
+	}
+
+	if f, ok := l.writer.w.(Flushable); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
 // Indicate that the logger would emit TRACE level logs
 func (l *intLogger) IsTrace() bool {
-	return Level(atomic.LoadInt32(l.level)) == Trace
+	return l.isEnabled(Trace)
 }
 
 // Indicate that the logger would emit DEBUG level logs
 func (l *intLogger) IsDebug() bool {
-	return Level(atomic.LoadInt32(l.level)) <= Debug
+	return l.isEnabled(Debug)
 }
 
 // Indicate that the logger would emit INFO level logs
 func (l *intLogger) IsInfo() bool {
-	return Level(atomic.LoadInt32(l.level)) <= Info
+	return l.isEnabled(Info)
 }
 
 // Indicate that the logger would emit WARN level logs
 func (l *intLogger) IsWarn() bool {
-	return Level(atomic.LoadInt32(l.level)) <= Warn
+	return l.isEnabled(Warn)
 }
 
 // Indicate that the logger would emit ERROR level logs
 func (l *intLogger) IsError() bool {
-	return Level(atomic.LoadInt32(l.level)) <= Error
+	return l.isEnabled(Error)
 }
 
 const MissingKey = "EXTRA_VALUE_AT_END"
@@ -775,6 +997,10 @@ func (l *intLogger) With(args ...interface{}) Logger {
 		sl.implied = append(sl.implied, MissingKey, extra)
 	}
 
+	if l.redactor != nil {
+		sl.implied = l.redactor.redactArgs(sl.implied)
+	}
+
 	return sl
 }
 
@@ -788,6 +1014,7 @@ func (l *intLogger) Named(name string) Logger {
 	} else {
 		sl.name = name
 	}
+	sl.overrideCache = new(levelOverrideCache)
 
 	return sl
 }
@@ -799,6 +1026,7 @@ func (l *intLogger) ResetNamed(name string) Logger {
 	sl := l.copy()
 
 	sl.name = name
+	sl.overrideCache = new(levelOverrideCache)
 
 	return sl
 }
@@ -811,6 +1039,11 @@ func (l *intLogger) ResetOutput(opts *LoggerOptions) error {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
+	// Any pending summary belongs with the entries in the old output.
+	if l.dedup != nil {
+		l.dedup.flush()
+	}
+
 	return l.resetOutput(opts)
 }
 
@@ -825,6 +1058,12 @@ func (l *intLogger) ResetOutputWithFlush(opts *LoggerOptions, flushable Flushabl
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
+	// Any pending summary belongs with the entries in the old output, so is
+	// written before it's flushed.
+	if l.dedup != nil {
+		l.dedup.flush()
+	}
+
 	if err := flushable.Flush(); err != nil {
 		return err
 	}
@@ -832,18 +1071,36 @@ func (l *intLogger) ResetOutputWithFlush(opts *LoggerOptions, flushable Flushabl
 	return l.resetOutput(opts)
 }
 
+// resetOutput switches to the new output. The lock must be held, and any
+// pending summary of repeated entries written first.
 func (l *intLogger) resetOutput(opts *LoggerOptions) error {
 	l.writer = newWriter(opts.Output, opts.Color)
 	l.setColorization(opts)
+
+	// setColorization may have turned the header color off for the new
+	// output, which the plain encoder needs to know about.
+	if pe, ok := l.encoder.(*plainEncoder); ok && pe.headerColor != l.headerColor {
+		npe := *pe
+		npe.headerColor = l.headerColor
+		l.encoder = &npe
+	}
+
 	return nil
 }
 
 // Update the logging level on-the-fly. This will affect all subloggers as
-// well.
+// well. A level set in LevelOverrides for the name of the logger takes
+// precedence.
 func (l *intLogger) SetLevel(level Level) {
 	atomic.StoreInt32(l.level, int32(level))
 }
 
+// Returns the level set with SetLevel, or the one the logger was created
+// with. A level set in LevelOverrides isn't taken into account.
+func (l *intLogger) GetLevel() Level {
+	return Level(atomic.LoadInt32(l.level))
+}
+
 // Create a *log.Logger that will send it's data through this Logger. This
 // allows packages that expect to be using the standard library log to actually
 // use this logger.
@@ -0,0 +1,248 @@
+package hclog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errJournaldWriterClosed is returned when writing to a closed JournaldWriter
+var errJournaldWriterClosed = errors.New("journald writer is closed")
+
+// JournaldSocket is the path of the socket journald listens on for the native
+// protocol.
+const JournaldSocket = "/run/systemd/journal/socket"
+
+// JournaldEncoder is an Encoder that writes entries in the journald native
+// protocol, with each key/value pair from the log call as a field of its own.
+// Keys are uppercased, and any characters other than letters, digits and
+// underscores are replaced with underscores. Keys that would clash with the
+// fields set by the encoder itself, such as MESSAGE or PRIORITY, are prefixed
+// with HCLOG_.
+//
+// The message is sent as MESSAGE, the level as PRIORITY, the logger name as
+// SYSLOG_IDENTIFIER and, with IncludeLocation, the caller as CODE_FILE and
+// CODE_LINE. journald adds its own timestamp, so the time of the entry is not
+// sent.
+//
+// It's meant to be used together with a JournaldWriter as the Output, which
+// sends each entry as a single datagram.
+type JournaldEncoder struct {
+	// The SYSLOG_IDENTIFIER used for loggers without a name. Defaults to the
+	// name of the program.
+	Identifier string
+}
+
+var _ Encoder = &JournaldEncoder{}
+
+// Encode writes the fields of the entry to buf.
+func (e *JournaldEncoder) Encode(buf *bytes.Buffer, t time.Time, name string, level Level, msg string, caller *runtime.Frame, implied, args []interface{}) error {
+	writeJournaldField(buf, "MESSAGE", msg)
+	writeJournaldField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(level)))
+
+	identifier := name
+	if identifier == "" {
+		identifier = e.Identifier
+	}
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+	writeJournaldField(buf, "SYSLOG_IDENTIFIER", identifier)
+
+	if caller != nil {
+		writeJournaldField(buf, "CODE_FILE", caller.File)
+		writeJournaldField(buf, "CODE_LINE", strconv.Itoa(caller.Line))
+		if caller.Function != "" {
+			writeJournaldField(buf, "CODE_FUNC", caller.Function)
+		}
+	}
+
+	args = append(implied, args...)
+
+	if len(args)%2 != 0 {
+		if cs, ok := args[len(args)-1].(CapturedStacktrace); ok {
+			args = args[:len(args)-1]
+			writeJournaldField(buf, "STACKTRACE", string(cs))
+		} else {
+			extra := args[len(args)-1]
+			args = append(args[:len(args)-1], MissingKey, extra)
+		}
+	}
+
+	for i := 0; i < len(args); i = i + 2 {
+		var val string
+
+		switch st := args[i+1].(type) {
+		case string:
+			val = st
+		case Quote:
+			val = string(st)
+		default:
+			val, _ = renderValue(st)
+		}
+
+		writeJournaldField(buf, journaldFieldName(renderKey(args[i])), val)
+	}
+
+	return nil
+}
+
+// writeJournaldField writes a single field. Values containing a newline are
+// written in the binary form, prefixed with their length.
+func writeJournaldField(buf *bytes.Buffer, key, val string) {
+	buf.WriteString(key)
+
+	if strings.IndexByte(val, '\n') == -1 {
+		buf.WriteByte('=')
+		buf.WriteString(val)
+		buf.WriteByte('\n')
+		return
+	}
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(val)))
+
+	buf.WriteByte('\n')
+	buf.Write(size[:])
+	buf.WriteString(val)
+	buf.WriteByte('\n')
+}
+
+// journaldReservedFields are the fields written by JournaldEncoder itself,
+// which keys from the log call must not duplicate or override.
+var journaldReservedFields = map[string]struct{}{
+	"MESSAGE":           {},
+	"PRIORITY":          {},
+	"SYSLOG_IDENTIFIER": {},
+	"CODE_FILE":         {},
+	"CODE_LINE":         {},
+	"CODE_FUNC":         {},
+	"STACKTRACE":        {},
+}
+
+// journaldFieldName converts key into a valid journal field name. Field names
+// may only contain uppercase letters, digits and underscores, must not start
+// with a digit or underscore, and are at most 64 characters long. Names
+// reserved by the encoder are prefixed with HCLOG_.
+func journaldFieldName(key string) string {
+	b := make([]byte, 0, len(key))
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			b = append(b, c-'a'+'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b = append(b, c)
+		default:
+			b = append(b, '_')
+		}
+	}
+
+	// Fields starting with an underscore are reserved for journald itself.
+	name := strings.TrimLeft(string(b), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "FIELD_" + name
+	}
+
+	if _, ok := journaldReservedFields[name]; ok {
+		name = "HCLOG_" + name
+	}
+
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	return name
+}
+
+// JournaldWriter is an io.Writer that sends each write as a single datagram
+// to journald, using the native protocol. It's meant to be used as the Output
+// for a Logger that uses a JournaldEncoder:
+//
+//	w, err := hclog.NewJournaldWriter("")
+//	...
+//	logger := hclog.New(&hclog.LoggerOptions{
+//		Output:  w,
+//		Encoder: &hclog.JournaldEncoder{},
+//	})
+//
+// Entries too large for a datagram are passed to journald in a sealed memfd
+// instead, where the platform supports it.
+type JournaldWriter struct {
+	addr *net.UnixAddr
+
+	mu     sync.Mutex
+	conn   *net.UnixConn
+	closed bool
+}
+
+// NewJournaldWriter returns a JournaldWriter for the journald socket at path,
+// or JournaldSocket if path is empty.
+func NewJournaldWriter(path string) (*JournaldWriter, error) {
+	if path == "" {
+		path = JournaldSocket
+	}
+
+	addr := &net.UnixAddr{Name: path, Net: "unixgram"}
+
+	// The socket is left unconnected, as passing a memfd requires giving the
+	// address with each message. Check journald is there now so that a
+	// missing socket is reported early.
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &JournaldWriter{addr: addr, conn: conn}, nil
+}
+
+// Write sends p to journald as a single entry.
+func (w *JournaldWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errJournaldWriterClosed
+	}
+
+	_, err := w.conn.WriteToUnix(p, w.addr)
+	if err == nil {
+		return len(p), nil
+	}
+
+	if !isMessageTooLarge(err) {
+		return 0, err
+	}
+
+	if err := sendJournaldMemfd(w.conn, w.addr, p); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close closes the connection to journald. Writes after Close return an error.
+func (w *JournaldWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	return w.conn.Close()
+}
@@ -0,0 +1,45 @@
+//go:build linux
+// +build linux
+
+package hclog
+
+import (
+	"errors"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// isMessageTooLarge indicates if err is from sending a datagram that is too
+// large for the socket.
+func isMessageTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendJournaldMemfd writes p to a sealed memfd and passes it to journald over
+// conn to addr, which is how entries too large for a datagram are sent.
+func sendJournaldMemfd(conn *net.UnixConn, addr *net.UnixAddr, p []byte) error {
+	fd, err := unix.MemfdCreate("hclog-journald", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	for written := 0; written < len(p); {
+		n, err := unix.Write(fd, p[written:])
+		if err != nil {
+			return err
+		}
+		written += n
+	}
+
+	// journald only accepts a memfd once it can no longer be changed.
+	seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err := unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, seals); err != nil {
+		return err
+	}
+
+	_, _, err = conn.WriteMsgUnix(nil, unix.UnixRights(fd), addr)
+	return err
+}
@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package hclog
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournaldWriter_Memfd(t *testing.T) {
+	server, path := listenJournald(t)
+
+	w, err := NewJournaldWriter(path)
+	require.NoError(t, err)
+	defer w.Close()
+
+	logger := New(&LoggerOptions{
+		Output:  w,
+		Encoder: &JournaldEncoder{},
+	})
+
+	// Far larger than the maximum datagram size.
+	big := strings.Repeat("x", 4<<20)
+	logger.Info("this is test", "big", big)
+
+	oob := make([]byte, syscall.CmsgSpace(4))
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := server.ReadMsgUnix(nil, oob)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	require.NoError(t, err)
+	require.Len(t, fds, 1)
+
+	f := os.NewFile(uintptr(fds[0]), "memfd")
+	defer f.Close()
+
+	// The memfd shares its offset with the writer, which left it at the end.
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+
+	fields := parseJournaldFields(t, data)
+	assert.Equal(t, "this is test", fields["MESSAGE"])
+	assert.True(t, fields["BIG"] == big, "big field was not sent intact")
+}
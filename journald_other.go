@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package hclog
+
+import (
+	"errors"
+	"net"
+)
+
+func isMessageTooLarge(err error) bool {
+	return false
+}
+
+func sendJournaldMemfd(conn *net.UnixConn, addr *net.UnixAddr, p []byte) error {
+	return errors.New("sending large entries to journald is only supported on linux")
+}
@@ -0,0 +1,240 @@
+package hclog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseJournaldFields decodes an entry in the journald native protocol.
+func parseJournaldFields(t *testing.T, b []byte) map[string]string {
+	fields := map[string]string{}
+
+	for len(b) > 0 {
+		nl := bytes.IndexByte(b, '\n')
+		require.NotEqual(t, -1, nl)
+
+		line := b[:nl]
+		b = b[nl+1:]
+
+		if eq := bytes.IndexByte(line, '='); eq != -1 {
+			fields[string(line[:eq])] = string(line[eq+1:])
+			continue
+		}
+
+		require.True(t, len(b) >= 8)
+		size := int(binary.LittleEndian.Uint64(b))
+		b = b[8:]
+
+		require.True(t, len(b) > size)
+		require.Equal(t, byte('\n'), b[size])
+		fields[string(line)] = string(b[:size])
+		b = b[size+1:]
+	}
+
+	return fields
+}
+
+// listenJournald returns a unixgram listener standing in for journald.
+func listenJournald(t *testing.T) (*net.UnixConn, string) {
+	dir, err := ioutil.TempDir("", "hclog-journald")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "socket")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, path
+}
+
+func TestJournaldEncoder(t *testing.T) {
+	t.Run("writes each pair as a field", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Name:    "test",
+			Output:  &buf,
+			Encoder: &JournaldEncoder{},
+		})
+
+		logger.Warn("this is test", "who", "programmer", "request-id", 12, "lines", "one\ntwo")
+
+		assert.Equal(t, map[string]string{
+			"MESSAGE":           "this is test",
+			"PRIORITY":          "4",
+			"SYSLOG_IDENTIFIER": "test",
+			"WHO":               "programmer",
+			"REQUEST_ID":        "12",
+			"LINES":             "one\ntwo",
+		}, parseJournaldFields(t, buf.Bytes()))
+	})
+
+	t.Run("maps levels to priorities", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:  &buf,
+			Level:   Trace,
+			Encoder: &JournaldEncoder{Identifier: "myapp"},
+		})
+
+		cases := []struct {
+			log      func(string, ...interface{})
+			priority string
+		}{
+			{logger.Trace, "7"},
+			{logger.Debug, "7"},
+			{logger.Info, "6"},
+			{logger.Warn, "4"},
+			{logger.Error, "3"},
+		}
+
+		for _, c := range cases {
+			buf.Reset()
+			c.log("this is test")
+
+			fields := parseJournaldFields(t, buf.Bytes())
+			assert.Equal(t, c.priority, fields["PRIORITY"])
+			assert.Equal(t, "myapp", fields["SYSLOG_IDENTIFIER"])
+		}
+	})
+
+	t.Run("includes the location", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:          &buf,
+			IncludeLocation: true,
+			Encoder:         &JournaldEncoder{},
+		})
+
+		logger.Info("this is test")
+
+		fields := parseJournaldFields(t, buf.Bytes())
+		assert.True(t, strings.HasSuffix(fields["CODE_FILE"], "journald_test.go"))
+		assert.NotEmpty(t, fields["CODE_LINE"])
+	})
+
+	t.Run("sanitizes field names", func(t *testing.T) {
+		cases := map[string]string{
+			"simple":      "SIMPLE",
+			"with.dots":   "WITH_DOTS",
+			"_private":    "PRIVATE",
+			"1st":         "FIELD_1ST",
+			"":            "FIELD_",
+			"ünicode":     "NICODE",
+			"MixedCase42": "MIXEDCASE42",
+			"message":     "HCLOG_MESSAGE",
+			"_priority":   "HCLOG_PRIORITY",
+			"code.file":   "HCLOG_CODE_FILE",
+			"message_id":  "MESSAGE_ID",
+		}
+
+		for key, name := range cases {
+			assert.Equal(t, name, journaldFieldName(key), key)
+		}
+
+		assert.Len(t, journaldFieldName(strings.Repeat("a", 100)), 64)
+	})
+
+	t.Run("keeps keys from overriding its own fields", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Name:            "app",
+			Output:          &buf,
+			Encoder:         &JournaldEncoder{},
+			IncludeLocation: true,
+		})
+
+		logger.Info("this is test",
+			"message", "forged",
+			"priority", 0,
+			"syslog_identifier", "sshd",
+			"code_file", "elsewhere.go",
+		)
+
+		out := "\n" + buf.String()
+		assert.Equal(t, 1, strings.Count(out, "\nMESSAGE="))
+		assert.Equal(t, 1, strings.Count(out, "\nPRIORITY="))
+
+		fields := parseJournaldFields(t, buf.Bytes())
+		assert.Equal(t, "this is test", fields["MESSAGE"])
+		assert.Equal(t, "6", fields["PRIORITY"])
+		assert.Equal(t, "app", fields["SYSLOG_IDENTIFIER"])
+		assert.True(t, strings.HasSuffix(fields["CODE_FILE"], "journald_test.go"))
+
+		assert.Equal(t, "forged", fields["HCLOG_MESSAGE"])
+		assert.Equal(t, "0", fields["HCLOG_PRIORITY"])
+		assert.Equal(t, "sshd", fields["HCLOG_SYSLOG_IDENTIFIER"])
+		assert.Equal(t, "elsewhere.go", fields["HCLOG_CODE_FILE"])
+	})
+
+	t.Run("includes the stacktrace", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:  &buf,
+			Encoder: &JournaldEncoder{},
+		})
+
+		logger.Error("this is test", "who", "programmer", Stacktrace())
+
+		fields := parseJournaldFields(t, buf.Bytes())
+		assert.Equal(t, "programmer", fields["WHO"])
+		assert.Contains(t, fields["STACKTRACE"], "TestJournaldEncoder")
+	})
+}
+
+func TestJournaldWriter(t *testing.T) {
+	t.Run("sends each entry as a datagram", func(t *testing.T) {
+		server, path := listenJournald(t)
+
+		w, err := NewJournaldWriter(path)
+		require.NoError(t, err)
+		defer w.Close()
+
+		logger := New(&LoggerOptions{
+			Name:    "test",
+			Output:  w,
+			Encoder: &JournaldEncoder{},
+		})
+
+		logger.Info("first", "n", 1)
+		logger.Info("second", "n", 2)
+
+		buf := make([]byte, 1024)
+		for _, msg := range []string{"first", "second"} {
+			server.SetReadDeadline(time.Now().Add(5 * time.Second))
+			n, err := server.Read(buf)
+			require.NoError(t, err)
+
+			fields := parseJournaldFields(t, buf[:n])
+			assert.Equal(t, msg, fields["MESSAGE"])
+			assert.Equal(t, "test", fields["SYSLOG_IDENTIFIER"])
+		}
+	})
+
+	t.Run("errors after close", func(t *testing.T) {
+		_, path := listenJournald(t)
+
+		w, err := NewJournaldWriter(path)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		_, err = w.Write([]byte("MESSAGE=closed\n"))
+		assert.Error(t, err)
+	})
+}
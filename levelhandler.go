@@ -0,0 +1,259 @@
+package hclog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LevelHandler is an http.Handler for viewing and changing the levels of
+// loggers at runtime, for example to turn on debug logging for one
+// subsystem in production without a restart.
+//
+// A GET request returns the registered loggers and their current levels as
+// JSON:
+//
+//	[{"name":"my-app","level":"info"},{"name":"my-app.raft","level":"debug","revert_at":"..."}]
+//
+// A PUT or POST request with a JSON body changes the level of a logger with
+// SetLevel. If ttl is given, as a duration such as "10m", the level is set
+// back to what it was once the ttl has passed:
+//
+//	{"name":"my-app.raft","level":"debug","ttl":"10m"}
+//
+// Loggers whose level is set by LevelOverrides are listed with
+// "overridden":true, and requests to change their level fail with 409
+// Conflict, since SetLevel would have no effect.
+//
+// Unless they were created with IndependentLevels, loggers derived from one
+// another share their level, so changing one changes them all.
+//
+// The handler does no authentication of its own, so it should only be
+// exposed on an admin listener or behind a wrapper that does.
+type LevelHandler struct {
+	mu      sync.Mutex
+	loggers map[string]Logger
+	reverts map[string]*levelRevert
+}
+
+// levelRevert is a pending change of a logger back to its previous level.
+type levelRevert struct {
+	level Level
+	at    time.Time
+	timer *time.Timer
+}
+
+// levelHandlerEntry is the JSON form of a logger, and the body of a request
+// to change its level.
+type levelHandlerEntry struct {
+	Name       string     `json:"name"`
+	Level      string     `json:"level"`
+	TTL        string     `json:"ttl,omitempty"`
+	RevertAt   *time.Time `json:"revert_at,omitempty"`
+	Overridden bool       `json:"overridden,omitempty"`
+}
+
+// levelOverrider is implemented by the loggers of this package, whose level
+// may be set by LevelOverrides.
+type levelOverrider interface {
+	overriddenLevel() (Level, bool)
+}
+
+var _ http.Handler = &LevelHandler{}
+
+// NewLevelHandler returns a LevelHandler for the given loggers.
+func NewLevelHandler(loggers ...Logger) *LevelHandler {
+	h := &LevelHandler{
+		loggers: map[string]Logger{},
+		reverts: map[string]*levelRevert{},
+	}
+
+	for _, l := range loggers {
+		h.Register(l)
+	}
+
+	return h
+}
+
+// Register adds l to the loggers the handler knows about, replacing any
+// logger registered with the same name.
+func (h *LevelHandler) Register(l Logger) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.loggers[l.Name()] = l
+}
+
+// ServeHTTP lists the loggers for GET requests, and changes the level of a
+// logger for PUT and POST requests.
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.serveList(w)
+	case http.MethodPut, http.MethodPost:
+		h.serveSet(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LevelHandler) serveList(w http.ResponseWriter) {
+	h.mu.Lock()
+
+	entries := make([]levelHandlerEntry, 0, len(h.loggers))
+	for name := range h.loggers {
+		entries = append(entries, h.entry(name))
+	}
+
+	h.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	writeLevelHandlerJSON(w, http.StatusOK, entries)
+}
+
+func (h *LevelHandler) serveSet(w http.ResponseWriter, r *http.Request) {
+	var req levelHandlerEntry
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	level := LevelFromString(req.Level)
+	if level == NoLevel {
+		http.Error(w, fmt.Sprintf("invalid level: %q", req.Level), http.StatusBadRequest)
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			http.Error(w, fmt.Sprintf("invalid ttl: %q", req.TTL), http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	l, ok := h.loggers[req.Name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown logger: %q", req.Name), http.StatusNotFound)
+		return
+	}
+
+	if o, ok := l.(levelOverrider); ok {
+		if _, overridden := o.overriddenLevel(); overridden {
+			http.Error(w, fmt.Sprintf("the level of logger %q is set by LevelOverrides", req.Name), http.StatusConflict)
+			return
+		}
+	}
+
+	// A pending revert keeps the level from before the first change, so
+	// that extending the ttl still ends up back where it started.
+	prev, pending := h.reverts[req.Name]
+	if pending {
+		prev.timer.Stop()
+		delete(h.reverts, req.Name)
+	}
+
+	if ttl > 0 {
+		revert := &levelRevert{
+			level: currentLevel(l),
+			at:    time.Now().Add(ttl),
+		}
+		if pending {
+			revert.level = prev.level
+		}
+
+		revert.timer = time.AfterFunc(ttl, func() {
+			h.revert(req.Name, revert)
+		})
+		h.reverts[req.Name] = revert
+	}
+
+	l.SetLevel(level)
+
+	writeLevelHandlerJSON(w, http.StatusOK, h.entry(req.Name))
+}
+
+// revert sets the logger back to its previous level, unless the revert has
+// since been replaced or cancelled.
+func (h *LevelHandler) revert(name string, revert *levelRevert) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.reverts[name] != revert {
+		return
+	}
+	delete(h.reverts, name)
+
+	if l, ok := h.loggers[name]; ok {
+		l.SetLevel(revert.level)
+	}
+}
+
+// entry returns the JSON form of the named logger. The lock must be held.
+func (h *LevelHandler) entry(name string) levelHandlerEntry {
+	l := h.loggers[name]
+
+	e := levelHandlerEntry{
+		Name:  name,
+		Level: currentLevel(l).String(),
+	}
+
+	if o, ok := l.(levelOverrider); ok {
+		if level, overridden := o.overriddenLevel(); overridden {
+			e.Level = level.String()
+			e.Overridden = true
+		}
+	}
+
+	if revert, ok := h.reverts[name]; ok {
+		at := revert.at.UTC()
+		e.RevertAt = &at
+	}
+
+	return e
+}
+
+func writeLevelHandlerJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// currentLevel returns the level l is set to. For loggers that aren't a
+// LevelGetter, it's worked out from the Is* methods, which can only tell
+// Trace through Error and Off apart.
+func currentLevel(l Logger) Level {
+	if g, ok := l.(LevelGetter); ok {
+		return g.GetLevel()
+	}
+
+	switch {
+	case l.IsTrace():
+		return Trace
+	case l.IsDebug():
+		return Debug
+	case l.IsInfo():
+		return Info
+	case l.IsWarn():
+		return Warn
+	case l.IsError():
+		return Error
+	default:
+		return Off
+	}
+}
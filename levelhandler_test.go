@@ -0,0 +1,194 @@
+package hclog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelHandler(t *testing.T) {
+	newLoggers := func() (Logger, Logger) {
+		root := New(&LoggerOptions{
+			Name:              "app",
+			Level:             Info,
+			Output:            &strings.Builder{},
+			IndependentLevels: true,
+		})
+		return root, root.Named("raft")
+	}
+
+	list := func(t *testing.T, h http.Handler) []levelHandlerEntry {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var entries []levelHandlerEntry
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+		return entries
+	}
+
+	set := func(h http.Handler, method, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/", strings.NewReader(body)))
+		return rec
+	}
+
+	t.Run("lists the loggers", func(t *testing.T) {
+		root, raft := newLoggers()
+		raft.SetLevel(Debug)
+
+		h := NewLevelHandler(raft, root)
+
+		assert.Equal(t, []levelHandlerEntry{
+			{Name: "app", Level: "info"},
+			{Name: "app.raft", Level: "debug"},
+		}, list(t, h))
+	})
+
+	t.Run("sets the level", func(t *testing.T) {
+		root, raft := newLoggers()
+		h := NewLevelHandler(root, raft)
+
+		rec := set(h, "PUT", `{"name":"app.raft","level":"trace"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"name":"app.raft","level":"trace"}`, rec.Body.String())
+
+		assert.True(t, raft.IsTrace())
+		assert.False(t, root.IsDebug())
+
+		rec = set(h, "POST", `{"name":"app","level":"error"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.False(t, root.IsWarn())
+	})
+
+	t.Run("reverts the level after the ttl", func(t *testing.T) {
+		_, raft := newLoggers()
+		h := NewLevelHandler(raft)
+
+		rec := set(h, "PUT", `{"name":"app.raft","level":"debug","ttl":"50ms"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var entry levelHandlerEntry
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entry))
+		require.NotNil(t, entry.RevertAt)
+		assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), *entry.RevertAt, time.Second)
+
+		assert.True(t, raft.IsDebug())
+
+		require.Eventually(t, func() bool {
+			return !raft.IsDebug()
+		}, 5*time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, []levelHandlerEntry{{Name: "app.raft", Level: "info"}}, list(t, h))
+	})
+
+	t.Run("reverts to registered and fatal levels", func(t *testing.T) {
+		withTestLevels(t)
+
+		for _, level := range []Level{testNotice, Fatal} {
+			_, raft := newLoggers()
+			raft.SetLevel(level)
+			h := NewLevelHandler(raft)
+
+			require.Equal(t, http.StatusOK, set(h, "PUT", `{"name":"app.raft","level":"debug","ttl":"20ms"}`).Code)
+
+			require.Eventually(t, func() bool {
+				return !raft.IsDebug()
+			}, 5*time.Second, 10*time.Millisecond)
+
+			assert.Equal(t, level, raft.(LevelGetter).GetLevel())
+			assert.Equal(t, []levelHandlerEntry{{Name: "app.raft", Level: level.String()}}, list(t, h))
+		}
+	})
+
+	t.Run("rejects changes to overridden levels", func(t *testing.T) {
+		overrides, err := ParseLevelOverrides("app.raft=trace")
+		require.NoError(t, err)
+
+		root := New(&LoggerOptions{
+			Name:           "app",
+			Level:          Info,
+			Output:         &strings.Builder{},
+			LevelOverrides: overrides,
+		})
+		raft := root.Named("raft")
+		h := NewLevelHandler(root, raft)
+
+		rec := set(h, "PUT", `{"name":"app.raft","level":"warn"}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Contains(t, rec.Body.String(), "LevelOverrides")
+		assert.True(t, raft.IsTrace())
+
+		assert.Equal(t, []levelHandlerEntry{
+			{Name: "app", Level: "info"},
+			{Name: "app.raft", Level: "trace", Overridden: true},
+		}, list(t, h))
+
+		require.Equal(t, http.StatusOK, set(h, "PUT", `{"name":"app","level":"warn"}`).Code)
+	})
+
+	t.Run("keeps the original level when the ttl is extended", func(t *testing.T) {
+		_, raft := newLoggers()
+		h := NewLevelHandler(raft)
+
+		require.Equal(t, http.StatusOK, set(h, "PUT", `{"name":"app.raft","level":"debug","ttl":"1h"}`).Code)
+		require.Equal(t, http.StatusOK, set(h, "PUT", `{"name":"app.raft","level":"trace","ttl":"50ms"}`).Code)
+		assert.True(t, raft.IsTrace())
+
+		require.Eventually(t, func() bool {
+			return !raft.IsDebug()
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.True(t, raft.IsInfo())
+	})
+
+	t.Run("cancels the revert when set without a ttl", func(t *testing.T) {
+		_, raft := newLoggers()
+		h := NewLevelHandler(raft)
+
+		require.Equal(t, http.StatusOK, set(h, "PUT", `{"name":"app.raft","level":"debug","ttl":"20ms"}`).Code)
+		require.Equal(t, http.StatusOK, set(h, "PUT", `{"name":"app.raft","level":"trace"}`).Code)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.True(t, raft.IsTrace())
+		assert.Nil(t, list(t, h)[0].RevertAt)
+	})
+
+	t.Run("rejects bad requests", func(t *testing.T) {
+		root, _ := newLoggers()
+		h := NewLevelHandler(root)
+
+		assert.Equal(t, http.StatusBadRequest, set(h, "PUT", `not json`).Code)
+		assert.Equal(t, http.StatusBadRequest, set(h, "PUT", `{"name":"app","level":"loud"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, set(h, "PUT", `{"name":"app","level":"debug","ttl":"soon"}`).Code)
+		assert.Equal(t, http.StatusNotFound, set(h, "PUT", `{"name":"other","level":"debug"}`).Code)
+
+		rec := set(h, "DELETE", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Equal(t, "GET, HEAD, PUT, POST", rec.Header().Get("Allow"))
+
+		assert.True(t, root.IsInfo())
+		assert.False(t, root.IsDebug())
+	})
+
+	t.Run("works with other loggers", func(t *testing.T) {
+		logger := NewInterceptLogger(&LoggerOptions{
+			Name:   "intercept",
+			Output: &strings.Builder{},
+		})
+		h := NewLevelHandler(logger, NewNullLogger())
+
+		require.Equal(t, http.StatusOK, set(h, "PUT", `{"name":"intercept","level":"warn"}`).Code)
+
+		assert.Equal(t, []levelHandlerEntry{
+			{Name: "", Level: "off"},
+			{Name: "intercept", Level: "warn"},
+		}, list(t, h))
+	})
+}
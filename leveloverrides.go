@@ -0,0 +1,243 @@
+package hclog
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LevelOverrides sets the level of loggers by name, overriding the level
+// they were created with. It's given to the root logger in LoggerOptions and
+// shared by every logger derived from it, so the level of a subsystem
+// created with Named can be changed without touching its code.
+//
+// Patterns are matched against the full name of a logger. A pattern matches
+// its own name and the names of all loggers below it, so "raft" applies to
+// both "raft" and "raft.snapshot". Patterns may also contain the wildcards
+// supported by path.Match, such as "raft.*" or "*.http". When several
+// patterns match, an exact match wins, then the longest pattern.
+//
+// LevelOverrides is safe for concurrent use, and changes take effect
+// immediately for all loggers using it.
+type LevelOverrides struct {
+	// generation is incremented on every change, so that loggers know to
+	// look up their level again. It's accessed atomically, so is kept first
+	// to ensure 64 bit alignment on 32 bit platforms.
+	generation uint64
+
+	mu     sync.RWMutex
+	levels map[string]Level
+}
+
+// NewLevelOverrides returns an empty LevelOverrides.
+func NewLevelOverrides() *LevelOverrides {
+	return &LevelOverrides{
+		levels: map[string]Level{},
+	}
+}
+
+// ParseLevelOverrides returns a LevelOverrides with the overrides in spec, a
+// comma separated list of pattern=level pairs such as
+// "raft.*=debug,http=warn".
+func ParseLevelOverrides(spec string) (*LevelOverrides, error) {
+	o := NewLevelOverrides()
+	if err := o.Replace(spec); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// Set sets the level of the loggers matching pattern. NoLevel isn't a valid
+// level; use Remove to stop overriding the level.
+func (o *LevelOverrides) Set(pattern string, level Level) error {
+	if err := checkLevelPattern(pattern); err != nil {
+		return err
+	}
+
+	if level == NoLevel {
+		return fmt.Errorf("invalid level in level override for %q: %s", pattern, level)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.levels[pattern] = level
+	atomic.AddUint64(&o.generation, 1)
+
+	return nil
+}
+
+// Remove removes the override for pattern, if there is one.
+func (o *LevelOverrides) Remove(pattern string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.levels, pattern)
+	atomic.AddUint64(&o.generation, 1)
+}
+
+// Replace replaces all the overrides with the ones in spec, in the format
+// accepted by ParseLevelOverrides. On error, the overrides are left as they
+// were.
+func (o *LevelOverrides) Replace(spec string) error {
+	levels := map[string]Level{}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		eq := strings.IndexByte(entry, '=')
+		if eq == -1 {
+			return fmt.Errorf("invalid level override, expected pattern=level: %q", entry)
+		}
+
+		pattern := strings.TrimSpace(entry[:eq])
+		if err := checkLevelPattern(pattern); err != nil {
+			return err
+		}
+
+		level := LevelFromString(strings.TrimSpace(entry[eq+1:]))
+		if level == NoLevel {
+			return fmt.Errorf("invalid level in level override: %q", entry)
+		}
+
+		levels[pattern] = level
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.levels = levels
+	atomic.AddUint64(&o.generation, 1)
+
+	return nil
+}
+
+// Lookup returns the level for the logger with the given name, and whether
+// any pattern matched it.
+func (o *LevelOverrides) Lookup(name string) (Level, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var (
+		best  string
+		level Level
+		found bool
+	)
+
+	for pattern, l := range o.levels {
+		if !matchLevelPattern(pattern, name) {
+			continue
+		}
+
+		if found && !moreSpecificPattern(pattern, best, name) {
+			continue
+		}
+
+		best, level, found = pattern, l, true
+	}
+
+	return level, found
+}
+
+// String returns the overrides in the format accepted by
+// ParseLevelOverrides, sorted by pattern.
+func (o *LevelOverrides) String() string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	entries := make([]string, 0, len(o.levels))
+	for pattern, level := range o.levels {
+		entries = append(entries, pattern+"="+level.String())
+	}
+	sort.Strings(entries)
+
+	return strings.Join(entries, ",")
+}
+
+func checkLevelPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("empty pattern in level override")
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern in level override %q: %s", pattern, err)
+	}
+
+	return nil
+}
+
+// matchLevelPattern reports whether pattern applies to the logger with the
+// given name, either directly or because it matches a parent of the logger.
+func matchLevelPattern(pattern, name string) bool {
+	for {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+
+		dot := strings.LastIndexByte(name, '.')
+		if dot == -1 {
+			return false
+		}
+		name = name[:dot]
+	}
+}
+
+// moreSpecificPattern reports whether pattern is a better match for name than
+// current.
+func moreSpecificPattern(pattern, current, name string) bool {
+	if (pattern == name) != (current == name) {
+		return pattern == name
+	}
+
+	if len(pattern) != len(current) {
+		return len(pattern) > len(current)
+	}
+
+	// Only to make the choice deterministic.
+	return pattern < current
+}
+
+// levelOverrideCache holds the result of looking up a name in a
+// LevelOverrides, so that it's only looked up again after a change or for a
+// different name.
+type levelOverrideCache struct {
+	result atomic.Value
+}
+
+type levelOverrideResult struct {
+	generation uint64
+	name       string
+	level      Level
+	found      bool
+}
+
+// lookup returns the overridden level for name, using the cached result if
+// it's for the same name and the overrides haven't changed since.
+func (c *levelOverrideCache) lookup(o *LevelOverrides, name string) (Level, bool) {
+	gen := atomic.LoadUint64(&o.generation)
+
+	r, ok := c.result.Load().(levelOverrideResult)
+	if ok && r.generation == gen && r.name == name {
+		return r.level, r.found
+	}
+
+	level, found := o.Lookup(name)
+
+	// The generation read above may be older than the overrides used, which
+	// only means the next call will look them up again.
+	c.result.Store(levelOverrideResult{
+		generation: gen,
+		name:       name,
+		level:      level,
+		found:      found,
+	})
+
+	return level, found
+}
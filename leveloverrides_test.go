@@ -0,0 +1,188 @@
+package hclog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelOverrides(t *testing.T) {
+	t.Run("parses a spec", func(t *testing.T) {
+		o, err := ParseLevelOverrides(" raft.*=debug, http=WARN ,,")
+		require.NoError(t, err)
+
+		assert.Equal(t, "http=warn,raft.*=debug", o.String())
+	})
+
+	t.Run("rejects NoLevel", func(t *testing.T) {
+		o := NewLevelOverrides()
+
+		assert.Error(t, o.Set("raft", NoLevel))
+		assert.Equal(t, "", o.String())
+	})
+
+	t.Run("rejects invalid specs", func(t *testing.T) {
+		for _, spec := range []string{"raft", "raft=loud", "=debug", "raft[=debug"} {
+			_, err := ParseLevelOverrides(spec)
+			assert.Error(t, err, spec)
+		}
+	})
+
+	t.Run("leaves the overrides alone when a replacement is invalid", func(t *testing.T) {
+		o, err := ParseLevelOverrides("raft=debug")
+		require.NoError(t, err)
+
+		assert.Error(t, o.Replace("http=warn,raft=loud"))
+		assert.Equal(t, "raft=debug", o.String())
+	})
+
+	t.Run("looks up the most specific match", func(t *testing.T) {
+		o, err := ParseLevelOverrides("raft=info,raft.*=debug,raft.snapshot=trace,*.http=warn,*=error")
+		require.NoError(t, err)
+
+		cases := map[string]Level{
+			"raft":               Info,
+			"raft.fsm":           Debug,
+			"raft.snapshot":      Trace,
+			"raft.snapshot.sink": Trace,
+			"api.http":           Warn,
+			"api.http.client":    Warn,
+			"api":                Error,
+			"":                   Error,
+		}
+
+		for name, want := range cases {
+			level, ok := o.Lookup(name)
+			assert.True(t, ok, name)
+			assert.Equal(t, want, level, name)
+		}
+	})
+
+	t.Run("matches descendants of plain names", func(t *testing.T) {
+		o := NewLevelOverrides()
+		require.NoError(t, o.Set("http", Warn))
+
+		level, ok := o.Lookup("http.server")
+		assert.True(t, ok)
+		assert.Equal(t, Warn, level)
+
+		_, ok = o.Lookup("https")
+		assert.False(t, ok)
+	})
+
+	t.Run("applies to named loggers", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		o, err := ParseLevelOverrides("app.raft.*=debug,app.http=warn")
+		require.NoError(t, err)
+
+		root := New(&LoggerOptions{
+			Name:           "app",
+			Output:         &buf,
+			DisableTime:    true,
+			LevelOverrides: o,
+		})
+
+		raft := root.Named("raft").Named("fsm")
+		http := root.Named("http")
+
+		raft.Debug("applied")
+		http.Info("request")
+		http.Warn("slow request")
+		root.Debug("hidden")
+		root.Info("shown")
+
+		assert.Equal(t, "[DEBUG] app.raft.fsm: applied\n[WARN]  app.http: slow request\n[INFO]  app: shown\n", buf.String())
+
+		assert.True(t, raft.IsDebug())
+		assert.False(t, http.IsInfo())
+		assert.False(t, root.IsDebug())
+	})
+
+	t.Run("can be changed at runtime", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		o := NewLevelOverrides()
+
+		logger := New(&LoggerOptions{
+			Output:         &buf,
+			DisableTime:    true,
+			LevelOverrides: o,
+		}).Named("raft")
+
+		logger.Debug("first")
+
+		require.NoError(t, o.Set("raft", Debug))
+		logger.Debug("second")
+
+		o.Remove("raft")
+		logger.Debug("third")
+
+		require.NoError(t, o.Replace("raft=trace"))
+		logger.Trace("fourth")
+
+		assert.Equal(t, "[DEBUG] raft: second\n[TRACE] raft: fourth\n", buf.String())
+	})
+
+	t.Run("takes precedence over SetLevel", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		o, err := ParseLevelOverrides("raft=error")
+		require.NoError(t, err)
+
+		logger := New(&LoggerOptions{
+			Output:         &buf,
+			DisableTime:    true,
+			LevelOverrides: o,
+		})
+		raft := logger.Named("raft")
+		other := logger.ResetNamed("other")
+
+		logger.SetLevel(Debug)
+
+		raft.Info("hidden")
+		other.Debug("shown")
+
+		assert.Equal(t, "[DEBUG] other: shown\n", buf.String())
+	})
+
+	t.Run("applies to the name of entries passed to sinks", func(t *testing.T) {
+		var buf, sbuf bytes.Buffer
+
+		o, err := ParseLevelOverrides("app.raft=debug,app.http=error")
+		require.NoError(t, err)
+
+		logger := NewInterceptLogger(&LoggerOptions{
+			Name:        "app",
+			Output:      &buf,
+			DisableTime: true,
+		})
+
+		sink := NewSinkAdapter(&LoggerOptions{
+			Name:           "sink",
+			Output:         &sbuf,
+			DisableTime:    true,
+			Level:          Info,
+			LevelOverrides: o,
+		})
+		logger.RegisterSink(sink)
+
+		logger.Named("raft").Debug("applied")
+		logger.Named("http").Warn("slow request")
+		logger.Info("started")
+
+		// Entries from both names are passed to the same sink.
+		sink.Accept("app.raft", Debug, "accepted")
+		sink.Accept("app.http", Warn, "dropped")
+
+		assert.Equal(t, strings.Join([]string{
+			"[DEBUG] app.raft: applied",
+			"[INFO]  app: started",
+			"[DEBUG] app.raft: accepted",
+			"",
+		}, "\n"), sbuf.String())
+	})
+}
@@ -0,0 +1,378 @@
+package hclog
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fatih/color"
+)
+
+// exitFunc is called by Fatal once the entry has been written. It's a
+// variable so that tests can replace it.
+var exitFunc = os.Exit
+
+// FatalLogger is implemented by the loggers of this package, which can log
+// at the Fatal and Panic levels and then exit or panic. It's separate from
+// Logger so that other implementations of Logger keep satisfying it. Use
+// LogFatal and LogPanic to do the same with any Logger.
+type FatalLogger interface {
+	// Emit a message and key/value pairs at the FATAL level, then exit the
+	// program with status 1 once the output is flushed
+	Fatal(msg string, args ...interface{})
+
+	// Emit a message and key/value pairs at the PANIC level, then panic with
+	// the message once the output is flushed
+	Panic(msg string, args ...interface{})
+}
+
+// LevelGetter is implemented by the loggers of this package, which can
+// report the level they're set to, including levels added with
+// RegisterLevel, which can't be told from the Is* methods.
+type LevelGetter interface {
+	// Returns the level set with SetLevel or LoggerOptions.Level. Any
+	// LevelOverrides for the name of the logger aren't taken into account.
+	GetLevel() Level
+}
+
+// LogFatal logs the message and key/value pairs at the Fatal level, then
+// exits the program with status 1. If logger is a FatalLogger, its Fatal
+// method is used, which flushes the output first. Otherwise the entry is
+// logged with Log, and logger is flushed if it is Flushable.
+func LogFatal(logger Logger, msg string, args ...interface{}) {
+	if fl, ok := logger.(FatalLogger); ok {
+		fl.Fatal(msg, args...)
+		return
+	}
+
+	logger.Log(Fatal, msg, args...)
+	if f, ok := logger.(Flushable); ok {
+		f.Flush()
+	}
+	exitFunc(1)
+}
+
+// LogPanic logs the message and key/value pairs at the Panic level, then
+// panics with the message, like LogFatal.
+func LogPanic(logger Logger, msg string, args ...interface{}) {
+	if fl, ok := logger.(FatalLogger); ok {
+		fl.Panic(msg, args...)
+		return
+	}
+
+	logger.Log(Panic, msg, args...)
+	if f, ok := logger.(Flushable); ok {
+		f.Flush()
+	}
+	panic(msg)
+}
+
+// LogAt logs the message and key/value pairs at level, using pc as the
+// location of the call when the logger includes it. It's meant for wrappers
+// around a Logger, which pass the program counter of their own caller, as
+// returned by runtime.Callers, so that the location isn't that of the
+// wrapper. Loggers not created by this package are given the entry with Log.
+// Unlike Fatal and Panic, LogAt doesn't exit or panic at those levels.
+func LogAt(logger Logger, level Level, pc uintptr, msg string, args ...interface{}) {
+	if el, ok := logger.(entryLogger); ok {
+		el.logEntry(logger.Name(), level, msg, pc, args...)
+		return
+	}
+
+	logger.Log(level, msg, args...)
+}
+
+// LevelDefinition describes a level registered with RegisterLevel.
+type LevelDefinition struct {
+	// The name of the level, as returned by Level.String and accepted by
+	// LevelFromString. Names are not case sensitive and must be unique.
+	Name string
+
+	// The text identifying the level in the plain format, such as
+	// "[NOTICE]". Defaults to the uppercased name in brackets.
+	Bracket string
+
+	// The color of the level when color is enabled. Defaults to no color.
+	Color *color.Color
+
+	// The name of the level in JSON output. Defaults to the name.
+	JSONName string
+
+	// Severity orders the level relative to the others, with more severe
+	// levels having a higher severity. The built-in levels have severities
+	// of 100 for Trace, 200 for Debug, 300 for Info, 400 for Warn, 500 for
+	// Error, 600 for Panic and 700 for Fatal. For example, a Notice level
+	// between Info and Warn could use 350. It must be greater than zero.
+	Severity int32
+}
+
+// levelInfo holds everything the formatters need to know about a level.
+type levelInfo struct {
+	name     string
+	bracket  string
+	color    *color.Color
+	jsonName string
+	severity int32
+}
+
+var (
+	// builtinLevels are the levels defined by this package, indexed by
+	// Level. They can't be replaced.
+	builtinLevels = [...]*levelInfo{
+		NoLevel: {name: "none", jsonName: "all", severity: 0},
+		Trace:   {name: "trace", bracket: "[TRACE]", color: color.New(color.FgHiGreen), jsonName: "trace", severity: 100},
+		Debug:   {name: "debug", bracket: "[DEBUG]", color: color.New(color.FgHiWhite), jsonName: "debug", severity: 200},
+		Info:    {name: "info", bracket: "[INFO] ", color: color.New(color.FgHiBlue), jsonName: "info", severity: 300},
+		Warn:    {name: "warn", bracket: "[WARN] ", color: color.New(color.FgHiYellow), jsonName: "warn", severity: 400},
+		Error:   {name: "error", bracket: "[ERROR]", color: color.New(color.FgHiRed), jsonName: "error", severity: 500},
+		Off:     {name: "off", jsonName: "all", severity: math.MaxInt32},
+		Panic:   {name: "panic", bracket: "[PANIC]", color: color.New(color.FgHiRed, color.Bold), jsonName: "panic", severity: 600},
+		Fatal:   {name: "fatal", bracket: "[FATAL]", color: color.New(color.FgHiRed, color.Bold), jsonName: "fatal", severity: 700},
+	}
+
+	// customLevels holds a map[Level]*levelInfo of the registered levels. It
+	// is replaced as a whole on every registration, so it can be read
+	// without locking. customLevelsMu serializes the registrations.
+	customLevels   atomic.Value
+	customLevelsMu sync.Mutex
+)
+
+// RegisterLevel adds a level, which can then be used with Log and SetLevel,
+// and is understood by all the built-in formats, LevelFromString and
+// StandardLogger's level inference. It's meant to be called during program
+// initialization:
+//
+//	const Notice hclog.Level = 100
+//
+//	func init() {
+//		hclog.RegisterLevel(Notice, hclog.LevelDefinition{
+//			Name:     "notice",
+//			Severity: 350,
+//		})
+//	}
+//
+// An error is returned if the level or its name is already in use.
+func RegisterLevel(level Level, def LevelDefinition) error {
+	if isBuiltinLevel(level) {
+		return fmt.Errorf("level %d is a built-in level", level)
+	}
+
+	name := strings.ToLower(strings.TrimSpace(def.Name))
+	if name == "" {
+		return fmt.Errorf("level %d has no name", level)
+	}
+
+	if def.Severity <= 0 || def.Severity == math.MaxInt32 {
+		return fmt.Errorf("level %q has an invalid severity: %d", name, def.Severity)
+	}
+
+	info := &levelInfo{
+		name:     name,
+		bracket:  def.Bracket,
+		color:    def.Color,
+		jsonName: def.JSONName,
+		severity: def.Severity,
+	}
+	if info.bracket == "" {
+		info.bracket = "[" + strings.ToUpper(name) + "]"
+	}
+	if info.jsonName == "" {
+		info.jsonName = name
+	}
+
+	customLevelsMu.Lock()
+	defer customLevelsMu.Unlock()
+
+	if findLevel(name) != NoLevel {
+		return fmt.Errorf("level name %q is already in use", name)
+	}
+
+	current := loadCustomLevels()
+	if _, ok := current[level]; ok {
+		return fmt.Errorf("level %d is already registered", level)
+	}
+
+	levels := make(map[Level]*levelInfo, len(current)+1)
+	for l, li := range current {
+		levels[l] = li
+	}
+	levels[level] = info
+
+	customLevels.Store(levels)
+
+	return nil
+}
+
+func loadCustomLevels() map[Level]*levelInfo {
+	levels, _ := customLevels.Load().(map[Level]*levelInfo)
+	return levels
+}
+
+func isBuiltinLevel(level Level) bool {
+	return level >= 0 && int(level) < len(builtinLevels)
+}
+
+// lookupLevel returns the information for a built-in or registered level.
+func lookupLevel(level Level) (*levelInfo, bool) {
+	if isBuiltinLevel(level) {
+		return builtinLevels[level], true
+	}
+
+	info, ok := loadCustomLevels()[level]
+	return info, ok
+}
+
+// findLevel returns the level with the given lowercase name, or NoLevel.
+func findLevel(name string) Level {
+	for level, info := range builtinLevels {
+		if info.name == name && Level(level) != NoLevel {
+			return Level(level)
+		}
+	}
+
+	for level, info := range loadCustomLevels() {
+		if info.name == name {
+			return level
+		}
+	}
+
+	return NoLevel
+}
+
+// levelSeverity returns the severity used to compare level against the
+// level of a logger. Unknown levels are ordered by their value, which
+// keeps them above Error as before levels could be registered.
+func levelSeverity(level Level) int32 {
+	if info, ok := lookupLevel(level); ok {
+		return info.severity
+	}
+
+	if level < 0 {
+		return 0
+	}
+	if level > math.MaxInt32/100 {
+		return math.MaxInt32 - 1
+	}
+
+	return int32(level) * 100
+}
+
+// levelsBySeverity returns the built-in levels from Trace to Fatal and the
+// registered levels, from the least to the most severe.
+func levelsBySeverity() []Level {
+	var levels []Level
+
+	for level := range builtinLevels {
+		if Level(level) != NoLevel && Level(level) != Off {
+			levels = append(levels, Level(level))
+		}
+	}
+
+	for level := range loadCustomLevels() {
+		levels = append(levels, level)
+	}
+
+	sort.Slice(levels, func(i, j int) bool {
+		return levelSeverity(levels[i]) < levelSeverity(levels[j])
+	})
+
+	return levels
+}
+
+// levelBracket returns the text for level in the plain format.
+func levelBracket(level Level) (string, bool) {
+	info, ok := lookupLevel(level)
+	if !ok || info.bracket == "" {
+		return "", false
+	}
+
+	return info.bracket, true
+}
+
+// levelColor returns the color for level, or nil if it has none.
+func levelColor(level Level) *color.Color {
+	if info, ok := lookupLevel(level); ok {
+		return info.color
+	}
+
+	return nil
+}
+
+// levelJSONName returns the name of level in JSON output.
+func levelJSONName(level Level) string {
+	if info, ok := lookupLevel(level); ok {
+		return info.jsonName
+	}
+
+	return "all"
+}
+
+// levelBrackets returns the brackets of all the levels that have one, without
+// padding, for inferring the level of a line. The built-in levels take
+// precedence over registered levels using the same bracket.
+func levelBrackets() map[string]Level {
+	brackets := map[string]Level{}
+
+	for level, info := range builtinLevels {
+		if info.bracket != "" {
+			brackets[strings.TrimSpace(info.bracket)] = Level(level)
+		}
+	}
+
+	for level, info := range loadCustomLevels() {
+		bracket := strings.TrimSpace(info.bracket)
+		if _, ok := brackets[bracket]; !ok {
+			brackets[bracket] = level
+		}
+	}
+
+	return brackets
+}
+
+// levelGuard returns the most severe of Trace through Error that is no more
+// severe than level, whose Is* method decides if level is enabled.
+func levelGuard(level Level) Level {
+	severity := levelSeverity(level)
+
+	guard := Trace
+	for _, l := range []Level{Debug, Info, Warn, Error} {
+		if builtinLevels[l].severity <= severity {
+			guard = l
+		}
+	}
+
+	return guard
+}
+
+// levelEnabler is implemented by the loggers of this package, which can tell
+// exactly whether they would emit entries at any level.
+type levelEnabler interface {
+	isEnabled(level Level) bool
+}
+
+// IsLevelEnabled reports whether l would emit entries at level. Other
+// Loggers than those of this package are asked through their Is* methods, so
+// levels between the built-in ones, such as registered levels, are treated
+// like the built-in level below them.
+func IsLevelEnabled(l Logger, level Level) bool {
+	if e, ok := l.(levelEnabler); ok {
+		return e.isEnabled(level)
+	}
+
+	switch levelGuard(level) {
+	case Trace:
+		return l.IsTrace()
+	case Debug:
+		return l.IsDebug()
+	case Info:
+		return l.IsInfo()
+	case Warn:
+		return l.IsWarn()
+	default:
+		return l.IsError()
+	}
+}
@@ -0,0 +1,379 @@
+package hclog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testNotice Level = 100
+	testAudit  Level = 101
+)
+
+var registerTestLevels sync.Once
+
+// withTestLevels registers the levels used by the tests, which can only be
+// done once per process.
+func withTestLevels(t *testing.T) {
+	registerTestLevels.Do(func() {
+		require.NoError(t, RegisterLevel(testNotice, LevelDefinition{
+			Name:     "Notice",
+			Severity: 350,
+		}))
+		require.NoError(t, RegisterLevel(testAudit, LevelDefinition{
+			Name:     "audit",
+			Bracket:  "[AUDIT]",
+			Color:    color.New(color.FgHiMagenta),
+			JSONName: "security-audit",
+			Severity: 550,
+		}))
+	})
+}
+
+// withExitFunc replaces exitFunc for the duration of the test, returning a
+// pointer to the code it was last called with.
+func withExitFunc(t *testing.T) *int {
+	code := -1
+
+	prev := exitFunc
+	exitFunc = func(c int) { code = c }
+	t.Cleanup(func() { exitFunc = prev })
+
+	return &code
+}
+
+type flushRecorder struct {
+	bytes.Buffer
+	flushed int
+}
+
+func (f *flushRecorder) Flush() error {
+	f.flushed++
+	return nil
+}
+
+func TestLogger_Fatal(t *testing.T) {
+	t.Run("logs, flushes and exits", func(t *testing.T) {
+		code := withExitFunc(t)
+
+		var out flushRecorder
+
+		logger := New(&LoggerOptions{
+			Name:   "test",
+			Level:  Off,
+			Output: &out,
+		})
+
+		LogFatal(logger, "this is test", "who", "programmer")
+
+		assert.Equal(t, 1, *code)
+		assert.Equal(t, 1, out.flushed)
+
+		// Off is more severe than Fatal, so nothing was logged.
+		assert.Empty(t, out.String())
+
+		logger.SetLevel(Error)
+		LogFatal(logger, "this is test", "who", "programmer")
+
+		str := out.String()
+		dataIdx := strings.IndexByte(str, ' ')
+		assert.Equal(t, "[FATAL] test: this is test: who=programmer\n", str[dataIdx+1:])
+	})
+
+	t.Run("uses the fatal level name in json", func(t *testing.T) {
+		withExitFunc(t)
+
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:     &buf,
+			JSONFormat: true,
+		})
+
+		LogFatal(logger, "this is test")
+
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &raw))
+		assert.Equal(t, "fatal", raw["level"])
+	})
+
+	t.Run("reaches the sinks of an intercept logger", func(t *testing.T) {
+		code := withExitFunc(t)
+
+		var buf, sbuf bytes.Buffer
+
+		logger := NewInterceptLogger(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+		})
+
+		var sinkOut flushRecorder
+		logger.RegisterSink(NewSinkAdapter(&LoggerOptions{
+			Output:      &sinkOut,
+			DisableTime: true,
+		}))
+		logger.RegisterSink(NewSinkAdapter(&LoggerOptions{
+			Output:      &sbuf,
+			DisableTime: true,
+		}))
+
+		LogFatal(logger, "this is test")
+
+		assert.Equal(t, 1, *code)
+		assert.Equal(t, "[FATAL] this is test\n", buf.String())
+		assert.Equal(t, "[FATAL] this is test\n", sinkOut.String())
+		assert.Equal(t, "[FATAL] this is test\n", sbuf.String())
+		assert.Equal(t, 1, sinkOut.flushed)
+	})
+
+	t.Run("exits from a null logger", func(t *testing.T) {
+		code := withExitFunc(t)
+
+		LogFatal(NewNullLogger(), "this is test")
+		assert.Equal(t, 1, *code)
+	})
+
+	t.Run("logs and flushes a Logger without Fatal", func(t *testing.T) {
+		code := withExitFunc(t)
+
+		var out flushRecorder
+
+		logger := &wrappedLogger{Logger: New(&LoggerOptions{
+			Output:      &out,
+			DisableTime: true,
+		})}
+
+		LogFatal(logger, "this is test")
+
+		assert.Equal(t, 1, *code)
+		assert.Equal(t, "[FATAL] this is test\n", out.String())
+		assert.Equal(t, 1, logger.flushed)
+	})
+}
+
+// wrappedLogger is a Logger implemented outside of this package, which
+// doesn't have the methods of FatalLogger.
+type wrappedLogger struct {
+	Logger
+	flushed int
+}
+
+func (w *wrappedLogger) Flush() error {
+	w.flushed++
+	return nil
+}
+
+func TestLogger_Panic(t *testing.T) {
+	var out flushRecorder
+
+	logger := New(&LoggerOptions{
+		Name:        "test",
+		Output:      &out,
+		DisableTime: true,
+	})
+
+	assert.PanicsWithValue(t, "this is test", func() {
+		LogPanic(logger, "this is test", "who", "programmer")
+	})
+
+	assert.Equal(t, "[PANIC] test: this is test: who=programmer\n", out.String())
+	assert.Equal(t, 1, out.flushed)
+
+	assert.PanicsWithValue(t, "this is test", func() {
+		LogPanic(NewNullLogger(), "this is test")
+	})
+
+	out.Reset()
+
+	assert.PanicsWithValue(t, "this is test", func() {
+		LogPanic(&wrappedLogger{Logger: logger}, "this is test")
+	})
+
+	assert.Equal(t, "[PANIC] test: this is test\n", out.String())
+}
+
+func TestLogAt(t *testing.T) {
+	// logVia logs through LogAt with the location of its caller, as a
+	// wrapper around a Logger would.
+	logVia := func(logger Logger, msg string) {
+		var pcs [1]uintptr
+		runtime.Callers(2, pcs[:])
+		LogAt(logger, Warn, pcs[0], msg)
+	}
+
+	t.Run("uses the location of the call", func(t *testing.T) {
+		var out, sout bytes.Buffer
+
+		logger := NewInterceptLogger(&LoggerOptions{
+			Name:            "test",
+			Output:          &out,
+			DisableTime:     true,
+			IncludeLocation: true,
+		})
+		logger.RegisterSink(NewSinkAdapter(&LoggerOptions{
+			Output:      &sout,
+			DisableTime: true,
+		}))
+
+		_, _, line, _ := runtime.Caller(0)
+		logVia(logger, "this is test")
+
+		assert.Contains(t, out.String(), fmt.Sprintf("levels_test.go:%d: test: this is test\n", line+1))
+		assert.Equal(t, "[WARN]  test: this is test\n", sout.String())
+	})
+
+	t.Run("uses Log for a Logger implemented elsewhere", func(t *testing.T) {
+		var out bytes.Buffer
+
+		logger := &wrappedLogger{Logger: New(&LoggerOptions{
+			Output:      &out,
+			DisableTime: true,
+		})}
+
+		logVia(logger, "this is test")
+
+		assert.Equal(t, "[WARN]  this is test\n", out.String())
+	})
+}
+
+func TestLevel_Order(t *testing.T) {
+	// Panic and Fatal are numbered in the order of their severity, though
+	// after Off.
+	assert.True(t, Panic < Fatal)
+	assert.True(t, levelSeverity(Error) < levelSeverity(Panic))
+	assert.True(t, levelSeverity(Panic) < levelSeverity(Fatal))
+	assert.True(t, levelSeverity(Fatal) < levelSeverity(Off))
+}
+
+func TestRegisterLevel(t *testing.T) {
+	withTestLevels(t)
+
+	t.Run("names the level", func(t *testing.T) {
+		assert.Equal(t, "notice", testNotice.String())
+		assert.Equal(t, testNotice, LevelFromString("NOTICE"))
+		assert.Equal(t, testAudit, LevelFromString(" audit "))
+		assert.Equal(t, Fatal, LevelFromString("fatal"))
+		assert.Equal(t, Panic, LevelFromString("panic"))
+		assert.Equal(t, "unknown", Level(1000).String())
+	})
+
+	t.Run("orders the level by severity", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Level:       Info,
+			Output:      &buf,
+			DisableTime: true,
+		})
+
+		logger.Log(testNotice, "noticed")
+		logger.SetLevel(Warn)
+		logger.Log(testNotice, "not noticed")
+		logger.Log(testAudit, "audited")
+		logger.SetLevel(Error)
+		logger.Log(testAudit, "still audited")
+
+		logger.SetLevel(testNotice)
+		assert.True(t, logger.IsWarn())
+		assert.False(t, logger.IsInfo())
+		logger.Info("hidden")
+		logger.Log(testNotice, "shown")
+
+		assert.Equal(t, strings.Join([]string{
+			"[NOTICE] noticed",
+			"[AUDIT] audited",
+			"[AUDIT] still audited",
+			"[NOTICE] shown",
+			"",
+		}, "\n"), buf.String())
+	})
+
+	t.Run("is enabled by severity", func(t *testing.T) {
+		assert.Equal(t, int32(350), testNotice.Severity())
+		assert.Equal(t, int32(700), Fatal.Severity())
+
+		logger := New(&LoggerOptions{Level: testNotice})
+
+		assert.True(t, IsLevelEnabled(logger, testNotice))
+		assert.True(t, IsLevelEnabled(logger, Warn))
+		assert.False(t, IsLevelEnabled(logger, Info))
+
+		intercept := NewInterceptLogger(&LoggerOptions{Level: testNotice})
+		assert.True(t, IsLevelEnabled(intercept, testNotice))
+		assert.False(t, IsLevelEnabled(intercept, Info))
+
+		// Other loggers can only be asked about the built-in levels, so
+		// Notice is treated like Info.
+		assert.False(t, IsLevelEnabled(&wrappedLogger{Logger: logger}, testNotice))
+		assert.True(t, IsLevelEnabled(&wrappedLogger{Logger: logger}, Warn))
+	})
+
+	t.Run("is used by all formats", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:     &buf,
+			JSONFormat: true,
+		})
+		logger.Log(testAudit, "audited")
+
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &raw))
+		assert.Equal(t, "security-audit", raw["level"])
+
+		buf.Reset()
+		logger = New(&LoggerOptions{
+			Output:       &buf,
+			OutputFormat: FormatLogfmt,
+			DisableTime:  true,
+		})
+		logger.Log(testNotice, "noticed")
+		assert.Equal(t, "level=notice msg=noticed\n", buf.String())
+
+		buf.Reset()
+		logger = New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+			Color:       ForceColor,
+		})
+		logger.Log(testAudit, "audited")
+		assert.Equal(t, color.New(color.FgHiMagenta).Sprint("[AUDIT] audited\n"), buf.String())
+
+		assert.Equal(t, 5, syslogSeverity(testNotice))
+		assert.Equal(t, 3, syslogSeverity(testAudit))
+		assert.Equal(t, 2, syslogSeverity(Fatal))
+	})
+
+	t.Run("is inferred by the standard logger", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+		})
+
+		std := logger.StandardLogger(&StandardLoggerOptions{InferLevels: true})
+		std.Print("[NOTICE] noticed")
+		std.Print("[FATAL] not fatal")
+
+		assert.Equal(t, "[NOTICE] noticed\n[FATAL] not fatal\n", buf.String())
+	})
+
+	t.Run("rejects invalid levels", func(t *testing.T) {
+		assert.Error(t, RegisterLevel(Info, LevelDefinition{Name: "info2", Severity: 300}))
+		assert.Error(t, RegisterLevel(testNotice, LevelDefinition{Name: "notice2", Severity: 300}))
+		assert.Error(t, RegisterLevel(200, LevelDefinition{Name: "NOTICE", Severity: 300}))
+		assert.Error(t, RegisterLevel(200, LevelDefinition{Name: "warn", Severity: 300}))
+		assert.Error(t, RegisterLevel(200, LevelDefinition{Name: "", Severity: 300}))
+		assert.Error(t, RegisterLevel(200, LevelDefinition{Name: "zero", Severity: 0}))
+	})
+}
@@ -0,0 +1,142 @@
+package hclog
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"time"
+	"unicode"
+)
+
+// logfmtEncoder is the built-in Encoder for FormatLogfmt.
+type logfmtEncoder struct {
+	timeFormat  string
+	disableTime bool
+}
+
+// Encode writes the entry as a single line of key=value pairs, starting with
+// ts, level, module, caller and msg, followed by the implied and given args.
+// Values are quoted following the logfmt rules, so multi-line values are
+// written with escaped newlines rather than indented like in the plain
+// format.
+func (e *logfmtEncoder) Encode(buf *bytes.Buffer, t time.Time, name string, level Level, msg string, caller *runtime.Frame, implied, args []interface{}) error {
+	first := true
+	writeKey := func(key string) {
+		if !first {
+			buf.WriteByte(' ')
+		}
+		first = false
+		writeLogfmtKey(buf, key)
+		buf.WriteByte('=')
+	}
+
+	if !e.disableTime {
+		writeKey("ts")
+		writeLogfmtString(buf, t.Format(e.timeFormat))
+	}
+
+	writeKey("level")
+	buf.WriteString(level.String())
+
+	if name != "" {
+		writeKey("module")
+		writeLogfmtString(buf, name)
+	}
+
+	if caller != nil {
+		writeKey("caller")
+		writeLogfmtString(buf, caller.File+":"+strconv.Itoa(caller.Line))
+	}
+
+	if msg != "" {
+		writeKey("msg")
+		writeLogfmtString(buf, msg)
+	}
+
+	args = append(implied, args...)
+
+	var stacktrace CapturedStacktrace
+
+	if len(args)%2 != 0 {
+		cs, ok := args[len(args)-1].(CapturedStacktrace)
+		if ok {
+			args = args[:len(args)-1]
+			stacktrace = cs
+		} else {
+			extra := args[len(args)-1]
+			args = append(args[:len(args)-1], MissingKey, extra)
+		}
+	}
+
+	for i := 0; i < len(args); i = i + 2 {
+		if st, ok := args[i+1].(CapturedStacktrace); ok {
+			stacktrace = st
+			continue
+		}
+
+		writeKey(renderKey(args[i]))
+
+		// Quote is already in a form that is valid logfmt, everything else
+		// is quoted only when required.
+		if q, ok := args[i+1].(Quote); ok {
+			buf.WriteString(strconv.Quote(string(q)))
+			continue
+		}
+
+		val, _ := renderValue(args[i+1])
+		if s, ok := args[i+1].(string); ok {
+			val = s
+		}
+		writeLogfmtString(buf, val)
+	}
+
+	if stacktrace != "" {
+		writeKey("stacktrace")
+		writeLogfmtString(buf, string(stacktrace))
+	}
+
+	buf.WriteString("\n")
+
+	return nil
+}
+
+// writeLogfmtKey writes key with any characters that are not allowed in a
+// logfmt key replaced with underscores.
+func writeLogfmtKey(w *bytes.Buffer, key string) {
+	if key == "" {
+		w.WriteByte('_')
+		return
+	}
+
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			w.WriteByte('_')
+		} else {
+			w.WriteRune(r)
+		}
+	}
+}
+
+// writeLogfmtString writes val, quoting it if it's empty or contains
+// characters that would otherwise break up the key=value pair.
+func writeLogfmtString(w *bytes.Buffer, val string) {
+	if logfmtNeedsQuoting(val) {
+		w.WriteString(strconv.Quote(val))
+	} else {
+		w.WriteString(val)
+	}
+}
+
+func logfmtNeedsQuoting(str string) bool {
+	if str == "" {
+		return true
+	}
+
+	for _, r := range str {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+
+	return false
+}
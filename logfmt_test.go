@@ -0,0 +1,155 @@
+package hclog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Logfmt(t *testing.T) {
+	t.Run("logfmt formatting", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Name:         "test",
+			Output:       &buf,
+			OutputFormat: FormatLogfmt,
+			TimeFn: func() time.Time {
+				return time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)
+			},
+		})
+
+		logger.Named("sub").Info("this is test", "who", "programmer", "why", "testing is fun")
+
+		assert.Equal(t, "ts=2021-06-01T12:30:00.000000Z level=info module=test.sub msg=\"this is test\" who=programmer why=\"testing is fun\"\n", buf.String())
+	})
+
+	t.Run("follows logfmt quoting rules", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:       &buf,
+			OutputFormat: FormatLogfmt,
+			DisableTime:  true,
+		})
+
+		logger.Warn("quoting",
+			"empty", "",
+			"equals", "a=b",
+			"quotes", `say "hi"`,
+			"backslash", `c:\dir`,
+			"unicode", "héllo",
+			"control", "bell\a",
+			"key with space", "ok",
+		)
+
+		expected := `level=warn msg=quoting empty="" equals="a=b" quotes="say \"hi\"" backslash="c:\\dir" unicode=héllo control="bell\a" key_with_space=ok` + "\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("escapes multi-line values", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:       &buf,
+			OutputFormat: FormatLogfmt,
+			DisableTime:  true,
+		})
+
+		logger.Error("failed\nbadly", "output", "line one\nline two\n")
+
+		assert.Equal(t, "level=error msg=\"failed\\nbadly\" output=\"line one\\nline two\\n\"\n", buf.String())
+	})
+
+	t.Run("supports value formatting", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:       &buf,
+			OutputFormat: FormatLogfmt,
+			DisableTime:  true,
+		})
+
+		logger.Info("formats",
+			"bandwidth", Fmt("%d GB/s", 200),
+			"hex", Hex(17),
+			"octal", Octal(17),
+			"binary", Binary(17),
+			"quoted", Quote("foo\nbar"),
+			"plainquote", Quote("foo"),
+			"slice", []int{1, 2},
+			"error", errors.New("boom"),
+		)
+
+		expected := `level=info msg=formats bandwidth="200 GB/s" hex=0x11 octal=021 binary=0b10001 quoted="foo\nbar" plainquote="foo" slice="[1, 2]" error=boom` + "\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("includes stacktraces as a field", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:       &buf,
+			OutputFormat: FormatLogfmt,
+			DisableTime:  true,
+		})
+
+		logger.Info("failed", "who", "programmer", Stacktrace())
+
+		str := buf.String()
+		assert.True(t, strings.HasPrefix(str, `level=info msg=failed who=programmer stacktrace="`), str)
+		assert.Contains(t, str, `stacktrace="github.com/TerminusDeus/go-hclog.Stacktrace\n`)
+		assert.Equal(t, 1, strings.Count(str, "\n"))
+	})
+
+	t.Run("includes implied args and missing keys", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:       &buf,
+			OutputFormat: FormatLogfmt,
+			DisableTime:  true,
+		})
+
+		logger.With("request", "abc").Info("", "extra")
+
+		assert.Equal(t, "level=info request=abc EXTRA_VALUE_AT_END=extra\n", buf.String())
+	})
+
+	t.Run("includes the caller location", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:          &buf,
+			OutputFormat:    FormatLogfmt,
+			DisableTime:     true,
+			IncludeLocation: true,
+		})
+
+		logger.Info("here")
+		_, file, line, _ := runtime.Caller(0)
+
+		assert.Equal(t, fmt.Sprintf("level=info caller=%s:%d msg=here\n", file, line-1), buf.String())
+	})
+
+	t.Run("JSONFormat takes precedence", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := New(&LoggerOptions{
+			Output:       &buf,
+			OutputFormat: FormatLogfmt,
+			JSONFormat:   true,
+			DisableTime:  true,
+		})
+
+		logger.Info("json")
+
+		assert.Equal(t, "{\"level\":\"info\",\"message\":\"json\"}\n", buf.String())
+	})
+}
@@ -1,9 +1,11 @@
 package hclog
 
 import (
+	"bytes"
 	"io"
 	"log"
 	"os"
+	"runtime"
 	"strings"
 	"time"
 )
@@ -17,6 +19,12 @@ var (
 )
 
 // Level represents a log level.
+//
+// Trace through Error are numbered in order of severity, with Off above
+// them. Panic, Fatal and the levels added with RegisterLevel are numbered
+// after Off, although they're less severe, so only Trace through Error and
+// Off can be compared by value. The loggers compare levels by the severity
+// given to them in the level registry instead.
 type Level int32
 
 const (
@@ -42,6 +50,16 @@ const (
 
 	// Off disables all logging output.
 	Off Level = 6
+
+	// Panic information about events that the current goroutine can't
+	// continue from. Logging with LogPanic panics once the output is
+	// flushed. Its severity is above Error and below Fatal.
+	Panic Level = 7
+
+	// Fatal information about events that the program can't continue from.
+	// Logging with LogFatal exits the program once the output is flushed.
+	// Its severity is above Panic and below Off.
+	Fatal Level = 8
 )
 
 // Format is a simple convenience type for when formatting is required. When
@@ -89,49 +107,44 @@ const (
 	ForceColor
 )
 
+// OutputFormat selects the format that log entries are written in.
+type OutputFormat uint8
+
+const (
+	// FormatPlain is the default human readable format, with a bracketed
+	// level and the key/value pairs following the message.
+	FormatPlain OutputFormat = iota
+	// FormatJSON writes each entry as a JSON object on a single line. This
+	// is the same as setting JSONFormat.
+	FormatJSON
+	// FormatLogfmt writes each entry as a single line of logfmt style
+	// key=value pairs, such as: ts=... level=info module=app msg="hello".
+	FormatLogfmt
+)
+
 // LevelFromString returns a Level type for the named log level, or "NoLevel" if
 // the level string is invalid. This facilitates setting the log level via
 // config or environment variable by name in a predictable way.
 func LevelFromString(levelStr string) Level {
 	// We don't care about case. Accept both "INFO" and "info".
 	levelStr = strings.ToLower(strings.TrimSpace(levelStr))
-	switch levelStr {
-	case "trace":
-		return Trace
-	case "debug":
-		return Debug
-	case "info":
-		return Info
-	case "warn":
-		return Warn
-	case "error":
-		return Error
-	case "off":
-		return Off
-	default:
-		return NoLevel
-	}
+
+	return findLevel(levelStr)
 }
 
 func (l Level) String() string {
-	switch l {
-	case Trace:
-		return "trace"
-	case Debug:
-		return "debug"
-	case Info:
-		return "info"
-	case Warn:
-		return "warn"
-	case Error:
-		return "error"
-	case NoLevel:
-		return "none"
-	case Off:
-		return "off"
-	default:
-		return "unknown"
+	if info, ok := lookupLevel(l); ok {
+		return info.name
 	}
+
+	return "unknown"
+}
+
+// Severity returns the severity the level is ordered by, as given in its
+// LevelDefinition for a registered level. See LevelDefinition for the
+// severities of the built-in levels.
+func (l Level) Severity() int32 {
+	return levelSeverity(l)
 }
 
 // Logger describes the interface that must be implemented by all loggers.
@@ -247,9 +260,19 @@ type LoggerOptions struct {
 	// log lines.
 	Mutex Locker
 
-	// Control if the output should be in JSON.
+	// Control if the output should be in JSON. If set, this overrides
+	// OutputFormat.
 	JSONFormat bool
 
+	// The format to write the output in. Defaults to FormatPlain.
+	OutputFormat OutputFormat
+
+	// An optional Encoder to format the entries with. If set, this is used
+	// instead of the built-in format selected by JSONFormat or OutputFormat,
+	// and TimeFormat, DisableTime and the color options other than Color are
+	// left to the Encoder.
+	Encoder Encoder
+
 	// Include file and line information in each log line
 	IncludeLocation bool
 
@@ -284,11 +307,39 @@ type LoggerOptions struct {
 	// message for (because it's too noisy, etc)
 	Exclude func(level Level, msg string, args ...interface{}) bool
 
+	// ExcludeNamed is like Exclude, but is also given the name of the logger,
+	// and the key/value pairs given to With ahead of those from the log call.
+	// An ExcludeByQuery can be used to build one from a query string.
+	ExcludeNamed func(name string, level Level, msg string, args ...interface{}) bool
+
 	// IndependentLevels causes subloggers to be created with an independent
 	// copy of this logger's level. This means that using SetLevel on this
 	// logger will not affect any subloggers, and SetLevel on any subloggers
 	// will not affect the parent or sibling loggers.
 	IndependentLevels bool
+
+	// LevelOverrides sets the level of this logger and the loggers derived
+	// from it based on their names, taking precedence over Level and
+	// SetLevel. The overrides can be changed at any time.
+	LevelOverrides *LevelOverrides
+
+	// Sampler, if set, limits how often entries with the same level and
+	// message are logged by this logger and the loggers derived from it.
+	Sampler *Sampler
+
+	// DeduplicateInterval, if set, collapses entries that exactly repeat the
+	// one before them into a summary, like syslog's "last message repeated
+	// N times". In JSON and the other structured formats, the summary is the
+	// entry again with a repeated field holding the count. The summary is
+	// written when a different entry is logged, on Flush or
+	// ResetOutputWithFlush, or once DeduplicateInterval has passed since the
+	// first repeat.
+	DeduplicateInterval time.Duration
+
+	// Redactor, if set, masks secrets and personal information in the
+	// messages and key/value pairs of this logger and the loggers derived
+	// from it.
+	Redactor *Redactor
 }
 
 // InterceptLogger describes the interface for using a logger
@@ -330,6 +381,18 @@ type SinkAdapter interface {
 	Accept(name string, level Level, msg string, args ...interface{})
 }
 
+// Encoder formats log entries for a Logger created by New. Encode is called
+// with the Logger's output lock held, and should append a single complete
+// entry, including any trailing newline, to buf. The implied args are those
+// given to With and args those given to the logging call; neither has been
+// checked for a missing value. caller is nil unless IncludeLocation is set.
+//
+// If Encode returns an error, the contents of buf are discarded and nothing
+// is written for the entry.
+type Encoder interface {
+	Encode(buf *bytes.Buffer, t time.Time, name string, level Level, msg string, caller *runtime.Frame, implied, args []interface{}) error
+}
+
 // Flushable represents a method for flushing an output buffer. It can be used
 // if Resetting the log to use a new output, in order to flush the writes to
 // the existing output beforehand.
@@ -1046,6 +1046,102 @@ func TestLogger_JSON(t *testing.T) {
 
 }
 
+type recordingEncoder struct {
+	names   []string
+	implied [][]interface{}
+	args    [][]interface{}
+	callers []*runtime.Frame
+	err     error
+}
+
+func (e *recordingEncoder) Encode(buf *bytes.Buffer, t time.Time, name string, level Level, msg string, caller *runtime.Frame, implied, args []interface{}) error {
+	e.names = append(e.names, name)
+	e.implied = append(e.implied, implied)
+	e.args = append(e.args, args)
+	e.callers = append(e.callers, caller)
+
+	fmt.Fprintf(buf, "%s|%s|%s", level, name, msg)
+	if e.err != nil {
+		return e.err
+	}
+	buf.WriteString("\n")
+	return nil
+}
+
+func TestLogger_Encoder(t *testing.T) {
+	t.Run("uses a custom encoder", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		enc := &recordingEncoder{}
+		logger := New(&LoggerOptions{
+			Name:       "test",
+			Output:     &buf,
+			Encoder:    enc,
+			JSONFormat: true,
+		})
+
+		logger.With("request", "abc").Warn("this is test", "who", "programmer")
+
+		assert.Equal(t, "warn|test|this is test\n", buf.String())
+		assert.Equal(t, []interface{}{"request", "abc"}, enc.implied[0])
+		assert.Equal(t, []interface{}{"who", "programmer"}, enc.args[0])
+		assert.Nil(t, enc.callers[0])
+	})
+
+	t.Run("passes the caller location", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		enc := &recordingEncoder{}
+		logger := New(&LoggerOptions{
+			Output:          &buf,
+			Encoder:         enc,
+			IncludeLocation: true,
+		})
+
+		logger.Info("this is test")
+		_, file, line, _ := runtime.Caller(0)
+
+		require.NotNil(t, enc.callers[0])
+		assert.Equal(t, file, enc.callers[0].File)
+		assert.Equal(t, line-1, enc.callers[0].Line)
+	})
+
+	t.Run("discards entries that fail to encode", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		enc := &recordingEncoder{err: errors.New("nope")}
+		logger := New(&LoggerOptions{
+			Output:  &buf,
+			Encoder: enc,
+		})
+
+		logger.Info("this is test")
+		enc.err = nil
+		logger.Info("this is another test")
+
+		assert.Equal(t, "info||this is another test\n", buf.String())
+	})
+
+	t.Run("intercept logger sinks use their own encoder", func(t *testing.T) {
+		var buf, sbuf bytes.Buffer
+
+		logger := NewInterceptLogger(&LoggerOptions{
+			Output:  &buf,
+			Encoder: &recordingEncoder{},
+		})
+		logger.RegisterSink(NewSinkAdapter(&LoggerOptions{
+			Output:       &sbuf,
+			OutputFormat: FormatLogfmt,
+			DisableTime:  true,
+		}))
+
+		logger.Named("sub").Error("this is test", "who", "programmer")
+
+		assert.Equal(t, "error|sub|this is test\n", buf.String())
+		assert.Equal(t, "level=error module=sub msg=\"this is test\" who=programmer\n", sbuf.String())
+	})
+}
+
 type customErrJSON struct {
 	Message string
 }
@@ -7,7 +7,8 @@ import (
 )
 
 // NewNullLogger instantiates a Logger for which all calls
-// will succeed without doing anything.
+// will succeed without doing anything. The exceptions are Fatal
+// and Panic, which still exit and panic respectively.
 // Useful for testing purposes.
 func NewNullLogger() Logger {
 	return &nullLogger{}
@@ -15,6 +16,9 @@ func NewNullLogger() Logger {
 
 type nullLogger struct{}
 
+var _ FatalLogger = &nullLogger{}
+var _ LevelGetter = &nullLogger{}
+
 func (l *nullLogger) Log(level Level, msg string, args ...interface{}) {}
 
 func (l *nullLogger) Trace(msg string, args ...interface{}) {}
@@ -27,6 +31,10 @@ func (l *nullLogger) Warn(msg string, args ...interface{}) {}
 
 func (l *nullLogger) Error(msg string, args ...interface{}) {}
 
+func (l *nullLogger) Fatal(msg string, args ...interface{}) { exitFunc(1) }
+
+func (l *nullLogger) Panic(msg string, args ...interface{}) { panic(msg) }
+
 func (l *nullLogger) IsTrace() bool { return false }
 
 func (l *nullLogger) IsDebug() bool { return false }
@@ -49,6 +57,8 @@ func (l *nullLogger) ResetNamed(name string) Logger { return l }
 
 func (l *nullLogger) SetLevel(level Level) {}
 
+func (l *nullLogger) GetLevel() Level { return Off }
+
 func (l *nullLogger) StandardLogger(opts *StandardLoggerOptions) *log.Logger {
 	return log.New(l.StandardWriter(opts), "", log.LstdFlags)
 }
@@ -0,0 +1,562 @@
+package hclog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ParseOptions can be used to configure how the plain output format is read
+// by Parse and a Scanner. They should match the LoggerOptions the output was
+// written with.
+type ParseOptions struct {
+	// The time format the entries were written with. Defaults to TimeFormat.
+	TimeFormat string
+
+	// Set if the entries were written without the time, with DisableTime.
+	DisableTime bool
+}
+
+// Record is an entry read back from the plain output format.
+type Record struct {
+	// Time is the zero time if the entries were written without it.
+	Time time.Time
+
+	// Level is NoLevel for entries written with an unknown level.
+	Level Level
+
+	// Caller is where the entry was logged from, if IncludeLocation was set.
+	// Its File holds only the last two elements of the path, as written.
+	Caller *runtime.Frame
+
+	Name    string
+	Message string
+
+	// Args holds the key/value pairs of the entry, as strings. Quoted values
+	// are unquoted, and multi-line values are joined back together.
+	Args []interface{}
+
+	// Stacktrace is the stacktrace written after the entry, if any.
+	Stacktrace CapturedStacktrace
+}
+
+// Parse reads a single entry written in the plain output format, which may
+// span several lines. If opts is nil, the defaults are used.
+//
+// The plain format isn't fully reversible, so Parse relies on the way it's
+// normally used. The name is the text before the first ": " if that text has
+// no spaces in it, so messages from unnamed loggers that look like
+// "name: message" are read as a name and a message. The key/value pairs
+// start at the first ":" after which the rest of the entry reads as pairs.
+// Backslashes in quoted values can't always be told apart from escapes, and
+// trailing newlines of multi-line values are lost.
+func Parse(text string, opts *ParseOptions) (*Record, error) {
+	return newEntryParser(opts).parse(text)
+}
+
+// Scanner reads the entries written in the plain output format from a
+// reader, such as a log file. It's used like a bufio.Scanner:
+//
+//	s := hclog.NewScanner(f, nil)
+//	for s.Scan() {
+//		rec := s.Record()
+//		...
+//	}
+//	if err := s.Err(); err != nil {
+//		...
+//	}
+//
+// Lines before the first entry, such as the end of an entry cut off by log
+// rotation, are skipped.
+type Scanner struct {
+	lines  *bufio.Scanner
+	parser *entryParser
+
+	// next is the first line of the next entry, if it's been read.
+	next    string
+	hasNext bool
+
+	record *Record
+	err    error
+}
+
+// maxLineSize is the longest line a Scanner reads.
+const maxLineSize = 1024 * 1024
+
+// NewScanner returns a Scanner reading from r. If opts is nil, the defaults
+// are used.
+func NewScanner(r io.Reader, opts *ParseOptions) *Scanner {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	return &Scanner{
+		lines:  lines,
+		parser: newEntryParser(opts),
+	}
+}
+
+// Scan reads the next entry, which is then available from Record. It
+// returns false at the end of the input or on an error.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	s.record = nil
+
+	if !s.hasNext {
+		for s.lines.Scan() {
+			if line := s.lines.Text(); s.parser.isStart(line) {
+				s.next, s.hasNext = line, true
+				break
+			}
+		}
+
+		if !s.hasNext {
+			s.err = s.lines.Err()
+			return false
+		}
+	}
+
+	var text strings.Builder
+	text.WriteString(s.next)
+	text.WriteByte('\n')
+	s.hasNext = false
+
+	for s.lines.Scan() {
+		line := s.lines.Text()
+		if s.parser.isStart(line) {
+			s.next, s.hasNext = line, true
+			break
+		}
+
+		text.WriteString(line)
+		text.WriteByte('\n')
+	}
+
+	if err := s.lines.Err(); err != nil {
+		s.err = err
+		return false
+	}
+
+	rec, err := s.parser.parse(text.String())
+	if err != nil {
+		s.err = err
+		return false
+	}
+
+	s.record = rec
+	return true
+}
+
+// Record returns the entry read by the last call to Scan.
+func (s *Scanner) Record() *Record {
+	return s.record
+}
+
+// Err returns the first error reading the input, if any.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+var (
+	// ansiEscape matches the color codes written with Color set.
+	ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+	// callerLocation matches the location written with IncludeLocation.
+	callerLocation = regexp.MustCompile(`^(\S+\.go):(\d+): `)
+)
+
+// entryParser reads entries in the plain output format.
+type entryParser struct {
+	timeFormat  string
+	disableTime bool
+
+	// brackets maps the brackets of the levels, without padding, to the
+	// levels.
+	brackets map[string]Level
+}
+
+func newEntryParser(opts *ParseOptions) *entryParser {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+
+	p := &entryParser{
+		timeFormat:  opts.TimeFormat,
+		disableTime: opts.DisableTime,
+		brackets:    levelBrackets(),
+	}
+
+	if p.timeFormat == "" {
+		p.timeFormat = TimeFormat
+	}
+
+	p.brackets["[?????]"] = NoLevel
+
+	return p
+}
+
+// isStart reports whether line is the first line of an entry.
+func (p *entryParser) isStart(line string) bool {
+	_, _, _, ok := p.header(ansiEscape.ReplaceAllString(line, ""))
+	return ok
+}
+
+// header reads the time and level from the start of line, returning the
+// rest of the line after the space following the level.
+func (p *entryParser) header(line string) (time.Time, Level, string, bool) {
+	var t time.Time
+
+	if !p.disableTime {
+		found := false
+
+		// The time format may itself contain spaces, so try each place the
+		// level could start.
+		for i := strings.Index(line, " ["); i >= 0; {
+			if parsed, err := time.Parse(p.timeFormat, line[:i]); err == nil {
+				t, found = parsed, true
+				line = line[i+1:]
+				break
+			}
+
+			next := strings.Index(line[i+1:], " [")
+			if next < 0 {
+				break
+			}
+			i += next + 1
+		}
+
+		if !found {
+			return t, NoLevel, "", false
+		}
+	}
+
+	end := strings.IndexByte(line, ']')
+	if !strings.HasPrefix(line, "[") || end < 0 {
+		return t, NoLevel, "", false
+	}
+
+	level, ok := p.brackets[line[:end+1]]
+	if !ok {
+		return t, NoLevel, "", false
+	}
+
+	written := line[:end+1]
+	if s, ok := levelBracket(level); ok {
+		written = s
+	}
+
+	if !strings.HasPrefix(line, written+" ") {
+		return t, NoLevel, "", false
+	}
+
+	return t, level, line[len(written)+1:], true
+}
+
+// parse reads a single entry.
+func (p *entryParser) parse(text string) (*Record, error) {
+	text = ansiEscape.ReplaceAllString(text, "")
+	text = strings.TrimSuffix(text, "\n")
+
+	t, level, body, ok := p.header(text)
+	if !ok {
+		first := text
+		if nl := strings.IndexByte(first, '\n'); nl >= 0 {
+			first = first[:nl]
+		}
+		return nil, fmt.Errorf("not an entry in the plain format: %q", first)
+	}
+
+	rec := &Record{
+		Time:  t,
+		Level: level,
+	}
+
+	if m := callerLocation.FindStringSubmatch(body); m != nil {
+		line, _ := strconv.Atoi(m[2])
+		rec.Caller = &runtime.Frame{File: m[1], Line: line}
+		body = body[len(m[0]):]
+	}
+
+	head := body
+
+	for i := 0; i < len(body); i++ {
+		if body[i] != ':' {
+			continue
+		}
+
+		args, rest, ok := parseArgs(body[i+1:])
+
+		// A ":" followed by nothing but a newline is only taken to start
+		// the key/value pairs if a stacktrace follows.
+		if !ok || (len(args) == 0 && rest == "") {
+			continue
+		}
+
+		head = body[:i]
+		rec.Args = args
+		if rest != "" {
+			rec.Stacktrace = CapturedStacktrace(rest[1:])
+		}
+		break
+	}
+
+	if i := strings.Index(head, ": "); i > 0 && !strings.ContainsAny(head[:i], " \n") {
+		rec.Name = head[:i]
+		rec.Message = head[i+2:]
+	} else {
+		rec.Message = head
+	}
+
+	return rec, nil
+}
+
+// parseArgs reads the key/value pairs that follow the ":" after the message,
+// returning what follows them, which is empty or starts with a newline.
+func parseArgs(s string) ([]interface{}, string, bool) {
+	var args []interface{}
+
+	for s != "" {
+		switch s[0] {
+		case ' ':
+			key, val, rest, ok := parseField(s[1:])
+			if !ok {
+				return nil, "", false
+			}
+
+			args = append(args, key, val)
+			s = rest
+		case '\n':
+			key, val, rest, ok := parseBlock(s)
+			if !ok {
+				return args, s, true
+			}
+
+			args = append(args, key, val)
+			s = rest
+		default:
+			return nil, "", false
+		}
+	}
+
+	return args, "", true
+}
+
+// parseKey reads a key up to the "=" following it.
+func parseKey(s string) (string, string, bool) {
+	eq := strings.IndexByte(s, '=')
+	if eq <= 0 || strings.ContainsAny(s[:eq], " \n\"") {
+		return "", "", false
+	}
+
+	return s[:eq], s[eq+1:], true
+}
+
+// parseField reads a key=value pair written on the same line as the message.
+func parseField(s string) (string, string, string, bool) {
+	key, s, ok := parseKey(s)
+	if !ok {
+		return "", "", "", false
+	}
+
+	var (
+		val string
+		n   int
+	)
+
+	switch {
+	case strings.HasPrefix(s, `"`):
+		val, n, ok = parseQuoted(s)
+		if !ok {
+			return "", "", "", false
+		}
+	case strings.HasPrefix(s, "["):
+		n = sliceEnd(s)
+		if n < 0 {
+			n = valueEnd(s)
+		}
+		val = s[:n]
+	default:
+		n = valueEnd(s)
+		val = s[:n]
+	}
+
+	rest := s[n:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\n' {
+		return "", "", "", false
+	}
+
+	return key, val, rest, true
+}
+
+// parseBlock reads a multi-line value, written as the key on a line of its
+// own followed by the lines of the value prefixed with "  | ".
+func parseBlock(s string) (string, string, string, bool) {
+	if !strings.HasPrefix(s, "\n  ") {
+		return "", "", "", false
+	}
+
+	key, s, ok := parseKey(s[3:])
+	if !ok || !strings.HasPrefix(s, "\n") {
+		return "", "", "", false
+	}
+	s = s[1:]
+
+	var lines []string
+	for strings.HasPrefix(s, "  | ") {
+		nl := strings.IndexByte(s, '\n')
+		if nl < 0 {
+			return "", "", "", false
+		}
+
+		lines = append(lines, unescapeOutput(s[4:nl], false))
+		s = s[nl+1:]
+	}
+
+	if len(lines) == 0 || !strings.HasPrefix(s, "  ") {
+		return "", "", "", false
+	}
+
+	return key, strings.Join(lines, "\n"), s[2:], true
+}
+
+// valueEnd returns the length of an unquoted value.
+func valueEnd(s string) int {
+	if n := strings.IndexAny(s, " \n"); n >= 0 {
+		return n
+	}
+
+	return len(s)
+}
+
+// sliceEnd returns the length of a slice rendered as "[a, b]", or -1 if s
+// doesn't start with one.
+func sliceEnd(s string) int {
+	depth := 0
+	inQuote := false
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\n':
+			return -1
+		case inQuote && c == '\\':
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				if i+1 < len(s) && s[i+1] != ' ' && s[i+1] != '\n' {
+					return -1
+				}
+				return i + 1
+			}
+		}
+	}
+
+	return -1
+}
+
+// parseQuoted reads a quoted value, returning it unquoted along with the
+// length of its quoted form. Values quoted with Quote are read as they were
+// given. Values escaped for output are too, unless they contain backslashes.
+func parseQuoted(s string) (string, int, bool) {
+	end := -1
+	for i := 1; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '\n':
+			return "", 0, false
+		case '"':
+			end = i
+		}
+	}
+
+	if end < 0 {
+		return "", 0, false
+	}
+
+	quoted := s[:end+1]
+	if val, err := strconv.Unquote(quoted); err == nil {
+		return val, len(quoted), true
+	}
+
+	return unescapeOutput(quoted[1:end], true), len(quoted), true
+}
+
+// unescapeOutput reverses writeEscapedForOutput. Backslashes that don't
+// start an escape are kept as they are.
+func unescapeOutput(s string, quotes bool) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch c := s[i+1]; c {
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '"':
+			if !quotes {
+				b.WriteByte('\\')
+				continue
+			}
+			b.WriteByte('"')
+		case 'x', 'u', 'U':
+			size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+			if i+2+size > len(s) {
+				b.WriteByte('\\')
+				continue
+			}
+
+			r, err := strconv.ParseUint(s[i+2:i+2+size], 16, 32)
+			if err != nil || (c != 'x' && !utf8.ValidRune(rune(r))) {
+				b.WriteByte('\\')
+				continue
+			}
+
+			if c == 'x' {
+				b.WriteByte(byte(r))
+			} else {
+				b.WriteRune(rune(r))
+			}
+			i += size
+		default:
+			b.WriteByte('\\')
+			continue
+		}
+
+		i++
+	}
+
+	return b.String()
+}
@@ -0,0 +1,282 @@
+package hclog
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 890000000, time.FixedZone("", -7*60*60))
+
+	write := func(opts *LoggerOptions, fn func(Logger)) string {
+		var buf bytes.Buffer
+
+		opts.Output = &buf
+		opts.Level = Trace
+		opts.TimeFn = func() time.Time { return now }
+
+		fn(New(opts))
+		return buf.String()
+	}
+
+	t.Run("round-trips every value type", func(t *testing.T) {
+		type custom struct{ A, B int }
+
+		cases := []struct {
+			name string
+			val  interface{}
+			want string
+		}{
+			{"string", "bar", "bar"},
+			{"string with spaces", "a b c", "a b c"},
+			{"empty string", "", ""},
+			{"escaped quotes", `say "hi"`, `say "hi"`},
+			{"escaped control characters", "tab\there\x01bell\a", "tab\there\x01bell\a"},
+			{"escaped unicode", "zero​width", "zero​width"},
+			{"escaped invalid utf-8", "bad\xffbyte ", "bad�byte "},
+			{"multi-line", "line 1\nline \"2\"\n\tline 3", "line 1\nline \"2\"\n\tline 3"},
+			{"Quote", Quote("a \"quoted\"\nvalue with \\ backslash"), "a \"quoted\"\nvalue with \\ backslash"},
+			{"empty Quote", Quote(""), ""},
+			{"int", 42, "42"},
+			{"negative int64", int64(-5), "-5"},
+			{"uint8", uint8(7), "7"},
+			{"float", 1.5, "1.5"},
+			{"bool", true, "true"},
+			{"Hex", Hex(255), "0xff"},
+			{"Octal", Octal(8), "010"},
+			{"Binary", Binary(5), "0b101"},
+			{"Format", Fmt("%d%%", 50), "50%"},
+			{"duration", 1500 * time.Millisecond, "1.5s"},
+			{"error", errors.New("connection refused"), "connection refused"},
+			{"struct", custom{1, 2}, "{1 2}"},
+			{"string slice", []string{"a", "b c"}, `["a", "b c"]`},
+			{"int slice", []int{1, 2}, "[1, 2]"},
+			{"empty slice", []string{}, "[]"},
+			{"brackets", "[not a slice", "[not a slice"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				out := write(&LoggerOptions{Name: "test"}, func(l Logger) {
+					l.Info("some message", "before", 1, "key", c.val, "after", "x y")
+				})
+
+				rec, err := Parse(out, nil)
+				require.NoError(t, err, out)
+
+				assert.Equal(t, []interface{}{"before", "1", "key", c.want, "after", "x y"}, rec.Args, out)
+				assert.Equal(t, "test", rec.Name)
+				assert.Equal(t, "some message", rec.Message)
+			})
+		}
+	})
+
+	t.Run("reads the header", func(t *testing.T) {
+		out := write(&LoggerOptions{Name: "my-app.db"}, func(l Logger) {
+			l.Warn("slow query")
+		})
+
+		rec, err := Parse(out, nil)
+		require.NoError(t, err)
+
+		assert.True(t, now.Equal(rec.Time), rec.Time)
+		assert.Equal(t, Warn, rec.Level)
+		assert.Nil(t, rec.Caller)
+		assert.Equal(t, "my-app.db", rec.Name)
+		assert.Equal(t, "slow query", rec.Message)
+		assert.Empty(t, rec.Args)
+		assert.Empty(t, rec.Stacktrace)
+	})
+
+	t.Run("reads every level", func(t *testing.T) {
+		for _, level := range []Level{Trace, Debug, Info, Warn, Error} {
+			out := write(&LoggerOptions{}, func(l Logger) {
+				l.Log(level, "entry")
+			})
+
+			rec, err := Parse(out, nil)
+			require.NoError(t, err)
+			assert.Equal(t, level, rec.Level)
+			assert.Equal(t, "entry", rec.Message)
+		}
+	})
+
+	t.Run("reads the caller", func(t *testing.T) {
+		var (
+			file string
+			line int
+		)
+		out := write(&LoggerOptions{Name: "test", IncludeLocation: true}, func(l Logger) {
+			_, file, line, _ = runtime.Caller(0)
+			l.Info("located", "a", 1)
+		})
+
+		rec, err := Parse(out, nil)
+		require.NoError(t, err)
+
+		require.NotNil(t, rec.Caller)
+		assert.Equal(t, trimCallerPath(file), rec.Caller.File)
+		assert.Equal(t, line+1, rec.Caller.Line)
+		assert.Equal(t, "test", rec.Name)
+		assert.Equal(t, "located", rec.Message)
+		assert.Equal(t, []interface{}{"a", "1"}, rec.Args)
+	})
+
+	t.Run("reads entries without a name", func(t *testing.T) {
+		out := write(&LoggerOptions{}, func(l Logger) {
+			l.Info("request handled", "status", 200)
+		})
+
+		rec, err := Parse(out, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "", rec.Name)
+		assert.Equal(t, "request handled", rec.Message)
+		assert.Equal(t, []interface{}{"status", "200"}, rec.Args)
+	})
+
+	t.Run("reads messages with colons and newlines", func(t *testing.T) {
+		out := write(&LoggerOptions{Name: "test"}, func(l Logger) {
+			l.Error("failed: see below\nsecond line: a=b c", "error", "boom")
+		})
+
+		rec, err := Parse(out, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "test", rec.Name)
+		assert.Equal(t, "failed: see below\nsecond line: a=b c", rec.Message)
+		assert.Equal(t, []interface{}{"error", "boom"}, rec.Args)
+	})
+
+	t.Run("reads a value without a key", func(t *testing.T) {
+		out := write(&LoggerOptions{}, func(l Logger) {
+			l.Info("odd", "a", 1, "extra")
+		})
+
+		rec, err := Parse(out, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"a", "1", MissingKey, "extra"}, rec.Args)
+	})
+
+	t.Run("reads consecutive multi-line values", func(t *testing.T) {
+		out := write(&LoggerOptions{}, func(l Logger) {
+			l.Info("blocks", "a", "1\n2", "b", "3\n4")
+		})
+
+		rec, err := Parse(out, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"a", "1\n2", "b", "3\n4"}, rec.Args)
+	})
+
+	t.Run("reads the stacktrace", func(t *testing.T) {
+		stack := CapturedStacktrace("main.main\n\t/src/main.go:10")
+
+		out := write(&LoggerOptions{Name: "test"}, func(l Logger) {
+			l.Error("crashed", "code", 2, "stack", "a\nb", stack)
+		})
+
+		rec, err := Parse(out, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "crashed", rec.Message)
+		assert.Equal(t, []interface{}{"code", "2", "stack", "a\nb"}, rec.Args)
+		assert.Equal(t, stack, rec.Stacktrace)
+
+		out = write(&LoggerOptions{}, func(l Logger) {
+			l.Error("crashed", stack)
+		})
+
+		rec, err = Parse(out, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "crashed", rec.Message)
+		assert.Empty(t, rec.Args)
+		assert.Equal(t, stack, rec.Stacktrace)
+	})
+
+	t.Run("reads output written with options", func(t *testing.T) {
+		out := write(&LoggerOptions{Name: "test", DisableTime: true, Color: ForceColor}, func(l Logger) {
+			l.Error("colored", "a", 1)
+		})
+
+		rec, err := Parse(out, &ParseOptions{DisableTime: true})
+		require.NoError(t, err)
+		assert.True(t, rec.Time.IsZero())
+		assert.Equal(t, Error, rec.Level)
+		assert.Equal(t, "colored", rec.Message)
+		assert.Equal(t, []interface{}{"a", "1"}, rec.Args)
+
+		out = write(&LoggerOptions{TimeFormat: time.RFC1123Z}, func(l Logger) {
+			l.Info("custom time")
+		})
+
+		rec, err = Parse(out, &ParseOptions{TimeFormat: time.RFC1123Z})
+		require.NoError(t, err)
+		assert.Equal(t, now.Unix(), rec.Time.Unix())
+		assert.Equal(t, "custom time", rec.Message)
+	})
+
+	t.Run("reads unknown levels", func(t *testing.T) {
+		rec, err := Parse("[?????] test: odd level\n", &ParseOptions{DisableTime: true})
+		require.NoError(t, err)
+		assert.Equal(t, NoLevel, rec.Level)
+		assert.Equal(t, "odd level", rec.Message)
+	})
+
+	t.Run("rejects other text", func(t *testing.T) {
+		_, err := Parse("just some text\n", nil)
+		assert.EqualError(t, err, `not an entry in the plain format: "just some text"`)
+
+		_, err = Parse("[INFO]  no time", nil)
+		assert.Error(t, err)
+
+		_, err = Parse("2021-03-04T05:06:07.890-0700 [NOPE] message", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestScanner(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := New(&LoggerOptions{
+		Name:   "test",
+		Level:  Trace,
+		Output: &buf,
+	})
+
+	logger.Info("first", "a", 1)
+	logger.Warn("second", "lines", "x\ny")
+	logger.Error("third", Stacktrace())
+	logger.Named("sub").Debug("fourth: with colon", "q", Quote("z"))
+
+	input := "cut off by rotation\n  | more\n" + buf.String()
+
+	s := NewScanner(strings.NewReader(input), nil)
+
+	var recs []*Record
+	for s.Scan() {
+		recs = append(recs, s.Record())
+	}
+	require.NoError(t, s.Err())
+	require.Len(t, recs, 4)
+
+	assert.Equal(t, "first", recs[0].Message)
+	assert.Equal(t, []interface{}{"a", "1"}, recs[0].Args)
+
+	assert.Equal(t, Warn, recs[1].Level)
+	assert.Equal(t, []interface{}{"lines", "x\ny"}, recs[1].Args)
+
+	assert.Equal(t, "third", recs[2].Message)
+	assert.Contains(t, string(recs[2].Stacktrace), "TestScanner")
+
+	assert.Equal(t, Debug, recs[3].Level)
+	assert.Equal(t, "test.sub", recs[3].Name)
+	assert.Equal(t, "fourth: with colon", recs[3].Message)
+	assert.Equal(t, []interface{}{"q", "z"}, recs[3].Args)
+
+	assert.False(t, s.Scan())
+	assert.Nil(t, s.Record())
+}
@@ -0,0 +1,257 @@
+package hclog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redactedText is what Secret values are written as.
+const redactedText = "[REDACTED]"
+
+// Secret wraps a string that must never be written out, such as a password
+// or token, so that it is logged as [REDACTED] in every format. It also
+// redacts itself when formatted with the fmt package or marshaled to JSON,
+// so it stays hidden when passed to Fmt. For example:
+// L.Info("connecting", "password", Secret(password))
+type Secret string
+
+// String returns [REDACTED].
+func (s Secret) String() string {
+	return redactedText
+}
+
+// GoString returns [REDACTED], so that the value is hidden with %#v.
+func (s Secret) GoString() string {
+	return redactedText
+}
+
+// Format writes [REDACTED] for every verb.
+func (s Secret) Format(f fmt.State, verb rune) {
+	f.Write([]byte(redactedText))
+}
+
+// MarshalJSON returns [REDACTED] as a JSON string.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redactedText)
+}
+
+// MarshalText returns [REDACTED].
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(redactedText), nil
+}
+
+// DefaultRedactKeys are the keys whose values are redacted by a Redactor
+// created without options.
+var DefaultRedactKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"authorization",
+	"api_key",
+	"apikey",
+	"access_key",
+	"private_key",
+	"cookie",
+	"set-cookie",
+}
+
+var (
+	// RedactJWT matches JSON Web Tokens.
+	RedactJWT = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+	// RedactAWSAccessKey matches AWS access key IDs.
+	RedactAWSAccessKey = regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`)
+
+	// RedactCreditCard matches the card numbers of the major card networks,
+	// optionally with spaces or dashes between the groups of digits.
+	RedactCreditCard = regexp.MustCompile(`\b(?:(?:4\d{3}|5[1-5]\d{2}|2[2-7]\d{2}|6011|65\d{2})(?:[ -]?\d{4}){3}|3[47]\d{2}[ -]?\d{6}[ -]?\d{5})\b`)
+)
+
+// DefaultRedactPatterns are the patterns redacted from values and messages by
+// a Redactor created without options.
+var DefaultRedactPatterns = []*regexp.Regexp{
+	RedactJWT,
+	RedactAWSAccessKey,
+	RedactCreditCard,
+}
+
+// RedactOptions can be used to configure a Redactor.
+type RedactOptions struct {
+	// Keys whose values are replaced entirely. Keys are matched without
+	// regard to case, and also match keys ending in them after a '_', '-',
+	// '.' or space, so "token" matches "api_token" and "X-Auth-Token". In
+	// messages and string values, text like "token=abc" is redacted too.
+	// To extend the defaults, use append(hclog.DefaultRedactKeys, ...).
+	Keys []string
+
+	// Patterns whose matches are replaced in messages and string values.
+	Patterns []*regexp.Regexp
+
+	// The text redacted values are replaced with. Defaults to [REDACTED].
+	Replacement string
+}
+
+// Redactor masks secrets and personal information before log entries are
+// written. It's given to a Logger in LoggerOptions, and applies to the
+// message and the key/value pairs from both the log call and With, so it
+// also covers lines written through StandardLogger and StandardWriter.
+//
+// The values of keys in the list are replaced entirely. Values that are
+// strings, Quote, Format or errors are searched for the patterns, and for
+// text like "password=hunter2". Other values, such as structs and maps, are
+// written as they are, so should be wrapped in Secret if they hold secrets.
+type Redactor struct {
+	keys        map[string]struct{}
+	patterns    []*regexp.Regexp
+	keyPattern  *regexp.Regexp
+	replacement string
+}
+
+// NewRedactor returns a Redactor with the given options. Without options, it
+// uses DefaultRedactKeys and DefaultRedactPatterns.
+func NewRedactor(opts *RedactOptions) *Redactor {
+	if opts == nil {
+		opts = &RedactOptions{
+			Keys:     DefaultRedactKeys,
+			Patterns: DefaultRedactPatterns,
+		}
+	}
+
+	r := &Redactor{
+		keys:        make(map[string]struct{}, len(opts.Keys)),
+		patterns:    opts.Patterns,
+		replacement: opts.Replacement,
+	}
+
+	if r.replacement == "" {
+		r.replacement = redactedText
+	}
+
+	quoted := make([]string, 0, len(opts.Keys))
+	for _, k := range opts.Keys {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+
+		r.keys[k] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(k))
+	}
+
+	if len(quoted) > 0 {
+		// A key, then a separator, then the value, which may be quoted or
+		// start with an authorization scheme.
+		r.keyPattern = regexp.MustCompile(`(?i)(\b[\w.-]*?(?:` + strings.Join(quoted, "|") + `)["']?\s*[:=]\s*)` +
+			`("[^"]*"|'[^']*'|(?:(?:bearer|basic|digest|token)\s+)?[^\s"',;&]+)`)
+	}
+
+	return r
+}
+
+// RedactKey reports whether the values of key are redacted.
+func (r *Redactor) RedactKey(key string) bool {
+	key = strings.ToLower(key)
+
+	if _, ok := r.keys[key]; ok {
+		return true
+	}
+
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '_', '-', '.', ' ':
+			if _, ok := r.keys[key[i+1:]]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// RedactString returns s with any secrets it contains replaced.
+func (r *Redactor) RedactString(s string) string {
+	if r.keyPattern != nil {
+		s = r.keyPattern.ReplaceAllString(s, "${1}"+strings.Replace(r.replacement, "$", "$$", -1))
+	}
+
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, r.replacement)
+	}
+
+	return s
+}
+
+// redactArgs returns args with the values of redacted keys replaced, and the
+// other values that can hold text redacted. args is only copied if something
+// needs to change.
+func (r *Redactor) redactArgs(args []interface{}) []interface{} {
+	var out []interface{}
+
+	set := func(i int, v interface{}) {
+		if out == nil {
+			out = make([]interface{}, len(args))
+			copy(out, args)
+		}
+		out[i] = v
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		// A trailing value without a key, or a stacktrace.
+		if i+1 == len(args) {
+			if _, ok := args[i].(CapturedStacktrace); !ok {
+				if v, changed := r.redactValue(args[i]); changed {
+					set(i, v)
+				}
+			}
+			break
+		}
+
+		if r.RedactKey(renderKey(args[i])) {
+			set(i+1, r.replacement)
+			continue
+		}
+
+		if v, changed := r.redactValue(args[i+1]); changed {
+			set(i+1, v)
+		}
+	}
+
+	if out == nil {
+		return args
+	}
+
+	return out
+}
+
+// redactValue returns v with any secrets it contains replaced, and whether
+// that changed it.
+func (r *Redactor) redactValue(v interface{}) (interface{}, bool) {
+	var s string
+
+	switch st := v.(type) {
+	case string:
+		s = st
+	case Quote:
+		if rs := r.RedactString(string(st)); rs != string(st) {
+			return Quote(rs), true
+		}
+		return v, false
+	case Format:
+		s = fmt.Sprintf(st[0].(string), st[1:]...)
+	case error:
+		s = st.Error()
+	case []byte:
+		s = string(st)
+	default:
+		return v, false
+	}
+
+	if rs := r.RedactString(s); rs != s {
+		return rs, true
+	}
+
+	return v, false
+}
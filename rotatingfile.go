@@ -0,0 +1,350 @@
+package hclog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// errRotatingFileClosed is returned when writing to a closed RotatingFileWriter
+var errRotatingFileClosed = errors.New("rotating file is closed")
+
+// RotatingFileOptions can be used to configure a RotatingFileWriter.
+type RotatingFileOptions struct {
+	// Path of the file to write to. Rotated files are kept next to it with a
+	// numeric suffix, with Path.1 being the most recent.
+	Path string
+
+	// Rotate the file before a write would take it over this many bytes.
+	// Zero disables rotation by size.
+	MaxBytes int64
+
+	// Rotate the file once it has been open for this long. Zero disables
+	// rotation by time.
+	Interval time.Duration
+
+	// The number of rotated files to keep. Older files are removed. If zero,
+	// the file is truncated when rotated.
+	MaxBackups int
+
+	// Compress the rotated files with gzip, adding a .gz suffix. Files are
+	// compressed in the background, and Close waits for that to finish.
+	Compress bool
+
+	// The permissions to create the file with. Defaults to 0640.
+	FileMode os.FileMode
+}
+
+// RotatingFileWriter is an io.Writer that writes to a file, rotating it by
+// size and/or time. It can be used as the Output for a Logger, and may also be
+// given to ResetOutputWithFlush as it implements Flushable.
+//
+// A RotatingFileWriter has its own lock, so it's safe to use from multiple
+// loggers regardless of their Mutex option, and to Reopen or Rotate while
+// loggers are writing to it.
+type RotatingFileWriter struct {
+	opts RotatingFileOptions
+	now  func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// closed is set by Close. Otherwise, a nil file means opening it failed,
+	// and it's opened again on the next write.
+	closed bool
+
+	// compressing tracks the background compression of a rotated file,
+	// which has to finish before the backups are moved along again.
+	compressing sync.WaitGroup
+}
+
+var _ Flushable = &RotatingFileWriter{}
+
+// NewRotatingFileWriter opens the file at opts.Path for appending, creating
+// it if required, and returns a RotatingFileWriter for it.
+func NewRotatingFileWriter(opts *RotatingFileOptions) (*RotatingFileWriter, error) {
+	if opts == nil || opts.Path == "" {
+		return nil, errors.New("no path given for rotating file")
+	}
+
+	w := &RotatingFileWriter{
+		opts: *opts,
+		now:  time.Now,
+	}
+	if w.opts.FileMode == 0 {
+		w.opts.FileMode = 0640
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write writes p to the file, first rotating it if required. If the file
+// couldn't be opened again after a rotation, opening it is retried.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.ensureOpen(); err != nil {
+		return 0, err
+	}
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// shouldRotate indicates if the file needs to be rotated before n more bytes
+// are written to it. A write is never split, so a file that is still empty
+// isn't rotated by size.
+func (w *RotatingFileWriter) shouldRotate(n int) bool {
+	if w.opts.MaxBytes > 0 && w.size > 0 && w.size+int64(n) > w.opts.MaxBytes {
+		return true
+	}
+
+	return w.opts.Interval > 0 && w.now().Sub(w.openedAt) >= w.opts.Interval
+}
+
+// Flush commits the contents of the file to stable storage.
+func (w *RotatingFileWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	return w.file.Sync()
+}
+
+// Rotate rotates the file now, regardless of its size or age.
+func (w *RotatingFileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.ensureOpen(); err != nil {
+		return err
+	}
+
+	return w.rotate()
+}
+
+// Reopen closes and reopens the file at the configured path. This is meant
+// to be used after the file has been moved away by an external tool like
+// logrotate, so that writes go to a new file at the original path.
+func (w *RotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	w.closed = false
+
+	return w.open()
+}
+
+// ReopenOnSignal calls Reopen whenever one of the given signals is received,
+// SIGHUP if none are given. The returned function stops listening for the
+// signals.
+func (w *RotatingFileWriter) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				w.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Close syncs and closes the file, and waits for any rotated file to be
+// compressed. Writes after Close return an error, but the file can be opened
+// again with Reopen.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	w.compressing.Wait()
+
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.file.Sync()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	w.file = nil
+
+	return err
+}
+
+// ensureOpen opens the file if opening it failed before. The lock must be
+// held.
+func (w *RotatingFileWriter) ensureOpen() error {
+	switch {
+	case w.closed:
+		return errRotatingFileClosed
+	case w.file == nil:
+		return w.open()
+	default:
+		return nil
+	}
+}
+
+func (w *RotatingFileWriter) open() error {
+	f, err := os.OpenFile(w.opts.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, w.opts.FileMode)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = fi.Size()
+	w.openedAt = w.now()
+
+	return nil
+}
+
+// rotate closes the current file, shifts the backups along and opens a new
+// file. The lock must be held.
+func (w *RotatingFileWriter) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return err
+	}
+
+	if w.opts.MaxBackups <= 0 {
+		if err := os.Remove(w.opts.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return w.open()
+	}
+
+	// Drop the oldest backup, then move each one along by one. The previous
+	// backup may still be being compressed, which is waited for so that it
+	// isn't moved from under it.
+	w.compressing.Wait()
+	w.removeBackup(w.opts.MaxBackups)
+	for i := w.opts.MaxBackups - 1; i > 0; i-- {
+		w.renameBackup(i, i+1)
+	}
+
+	first := w.backupPath(1)
+	if err := os.Rename(w.opts.Path, first); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	// Compressing is done in the background so that it doesn't hold up
+	// logging. Failing to compress leaves the uncompressed backup in place,
+	// which is no reason to fail the write that triggered the rotation.
+	if w.opts.Compress {
+		w.compressing.Add(1)
+		go func() {
+			defer w.compressing.Done()
+			compressFile(first)
+		}()
+	}
+
+	return nil
+}
+
+func (w *RotatingFileWriter) backupPath(n int) string {
+	return w.opts.Path + "." + strconv.Itoa(n)
+}
+
+func (w *RotatingFileWriter) removeBackup(n int) {
+	os.Remove(w.backupPath(n))
+	os.Remove(w.backupPath(n) + ".gz")
+}
+
+func (w *RotatingFileWriter) renameBackup(from, to int) {
+	os.Rename(w.backupPath(from), w.backupPath(to))
+	os.Rename(w.backupPath(from)+".gz", w.backupPath(to)+".gz")
+}
+
+// compressFile replaces the file at path with a gzipped copy at path.gz.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode())
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	in.Close()
+	return os.Remove(path)
+}
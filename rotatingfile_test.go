@@ -0,0 +1,253 @@
+package hclog
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFileWriter(t *testing.T) {
+	tempDir := func(t *testing.T) string {
+		dir, err := ioutil.TempDir("", "hclog-rotate")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		return dir
+	}
+
+	readFile := func(t *testing.T, path string) string {
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("rotates by size", func(t *testing.T) {
+		path := filepath.Join(tempDir(t), "app.log")
+
+		w, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path:       path,
+			MaxBytes:   10,
+			MaxBackups: 2,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+			_, err := w.Write([]byte(line))
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, "fourth\n", readFile(t, path))
+		assert.Equal(t, "third\n", readFile(t, path+".1"))
+		assert.Equal(t, "second\n", readFile(t, path+".2"))
+		assert.NoFileExists(t, path+".3")
+	})
+
+	t.Run("does not split writes larger than the max size", func(t *testing.T) {
+		path := filepath.Join(tempDir(t), "app.log")
+
+		w, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path:       path,
+			MaxBytes:   4,
+			MaxBackups: 1,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		_, err = w.Write([]byte("a long line\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "a long line\n", readFile(t, path))
+		assert.NoFileExists(t, path+".1")
+	})
+
+	t.Run("truncates without backups", func(t *testing.T) {
+		path := filepath.Join(tempDir(t), "app.log")
+
+		w, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path:     path,
+			MaxBytes: 8,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		w.Write([]byte("first\n"))
+		w.Write([]byte("second\n"))
+
+		assert.Equal(t, "second\n", readFile(t, path))
+		assert.NoFileExists(t, path+".1")
+	})
+
+	t.Run("rotates by time", func(t *testing.T) {
+		path := filepath.Join(tempDir(t), "app.log")
+
+		now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		w, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path:       path,
+			Interval:   time.Hour,
+			MaxBackups: 1,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		w.now = func() time.Time { return now }
+		w.openedAt = now
+
+		w.Write([]byte("first\n"))
+		now = now.Add(59 * time.Minute)
+		w.Write([]byte("second\n"))
+		now = now.Add(time.Minute)
+		w.Write([]byte("third\n"))
+
+		assert.Equal(t, "third\n", readFile(t, path))
+		assert.Equal(t, "first\nsecond\n", readFile(t, path+".1"))
+	})
+
+	t.Run("compresses rotated files", func(t *testing.T) {
+		path := filepath.Join(tempDir(t), "app.log")
+
+		w, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path:       path,
+			MaxBackups: 2,
+			Compress:   true,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		w.Write([]byte("first\n"))
+		require.NoError(t, w.Rotate())
+		w.Write([]byte("second\n"))
+		require.NoError(t, w.Rotate())
+
+		// Close waits for the compression in the background.
+		require.NoError(t, w.Close())
+
+		assert.NoFileExists(t, path+".1")
+		for i, expected := range []string{"second\n", "first\n"} {
+			f, err := os.Open(path + "." + string(rune('1'+i)) + ".gz")
+			require.NoError(t, err)
+
+			gz, err := gzip.NewReader(f)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(gz)
+			require.NoError(t, err)
+			f.Close()
+
+			assert.Equal(t, expected, string(data))
+		}
+	})
+
+	t.Run("appends to an existing file", func(t *testing.T) {
+		path := filepath.Join(tempDir(t), "app.log")
+		require.NoError(t, ioutil.WriteFile(path, []byte("existing\n"), 0600))
+
+		w, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path:       path,
+			MaxBytes:   12,
+			MaxBackups: 1,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		w.Write([]byte("new\n"))
+
+		assert.Equal(t, "new\n", readFile(t, path))
+		assert.Equal(t, "existing\n", readFile(t, path+".1"))
+	})
+
+	t.Run("reopens after external rotation", func(t *testing.T) {
+		path := filepath.Join(tempDir(t), "app.log")
+
+		w, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path: path,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		w.Write([]byte("before\n"))
+		require.NoError(t, os.Rename(path, path+".old"))
+
+		w.Write([]byte("moved\n"))
+		require.NoError(t, w.Reopen())
+		w.Write([]byte("after\n"))
+
+		assert.Equal(t, "before\nmoved\n", readFile(t, path+".old"))
+		assert.Equal(t, "after\n", readFile(t, path))
+	})
+
+	t.Run("retries opening after a failed rotation", func(t *testing.T) {
+		dir := tempDir(t)
+		path := filepath.Join(dir, "logs", "app.log")
+		require.NoError(t, os.Mkdir(filepath.Dir(path), 0700))
+
+		w, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path: path,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		// Rotating can't open the file again while the directory is gone.
+		require.NoError(t, os.RemoveAll(filepath.Dir(path)))
+		require.Error(t, w.Rotate())
+
+		_, err = w.Write([]byte("lost\n"))
+		require.Error(t, err)
+
+		require.NoError(t, os.Mkdir(filepath.Dir(path), 0700))
+		_, err = w.Write([]byte("found\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "found\n", readFile(t, path))
+	})
+
+	t.Run("errors after close", func(t *testing.T) {
+		path := filepath.Join(tempDir(t), "app.log")
+
+		w, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path: path,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, w.Close())
+		_, err = w.Write([]byte("closed\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("works as a logger output", func(t *testing.T) {
+		dir := tempDir(t)
+		path := filepath.Join(dir, "app.log")
+
+		w, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path: path,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		logger := New(&LoggerOptions{
+			Name:        "test",
+			Output:      w,
+			DisableTime: true,
+		})
+
+		logger.Info("this is test", "who", "programmer")
+
+		other, err := NewRotatingFileWriter(&RotatingFileOptions{
+			Path: filepath.Join(dir, "other.log"),
+		})
+		require.NoError(t, err)
+		defer other.Close()
+
+		require.NoError(t, logger.(OutputResettable).ResetOutputWithFlush(&LoggerOptions{Output: other}, w))
+		logger.Info("this is another test")
+
+		assert.Equal(t, "[INFO]  test: this is test: who=programmer\n", readFile(t, path))
+		assert.Equal(t, "[INFO]  test: this is another test\n", readFile(t, filepath.Join(dir, "other.log")))
+	})
+}
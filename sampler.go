@@ -0,0 +1,249 @@
+package hclog
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// samplerShards is the number of independently locked maps the counters of a
+// Sampler are spread over, to reduce contention between goroutines logging
+// different messages.
+const samplerShards = 32
+
+// SamplerOptions can be used to configure a Sampler.
+type SamplerOptions struct {
+	// The length of each sampling window. Defaults to one second.
+	Interval time.Duration
+
+	// The number of entries with the same level and message that are logged
+	// in each window before sampling starts. Defaults to 100.
+	First int
+
+	// Once First entries have been logged, only every Thereafter'th entry is
+	// logged for the rest of the window. If zero, no more are logged.
+	Thereafter int
+}
+
+// Sampler limits how often entries with the same level and message are
+// logged, so that a hot loop hitting the same error can't flood the output.
+// It's given to a Logger in LoggerOptions, and shared by every logger derived
+// from it.
+//
+// In each window, the first entries for a level and message are logged, then
+// only every Thereafter'th. When the window closes, a summary such as
+// "suppressed 12345 similar messages" is logged for each message that had
+// entries dropped, with the message as sampled_message and the count as
+// suppressed. Entries dropped by Exclude or ExcludeNamed don't count towards
+// the sample. Fatal and Panic entries are never sampled.
+//
+// The counters are updated atomically, with a read lock on one of several
+// shards to find them, so sampling adds little to the cost of logging. A
+// background goroutine closes the windows, so Stop should be called once the
+// Sampler is no longer needed.
+type Sampler struct {
+	first      uint64
+	thereafter uint64
+
+	shards [samplerShards]samplerShard
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+type samplerShard struct {
+	mu       sync.RWMutex
+	counters map[samplerKey]*samplerCounter
+}
+
+type samplerKey struct {
+	level Level
+	msg   string
+}
+
+type samplerCounter struct {
+	// These are accessed atomically, so are kept first to ensure 64 bit
+	// alignment on 32 bit platforms.
+	count      uint64
+	suppressed uint64
+
+	// source is the *samplerSource of the first entry suppressed in the
+	// window, which the summary is logged with.
+	source atomic.Value
+}
+
+// samplerSource is the logger, and name, that a summary is logged with.
+type samplerSource struct {
+	logger *intLogger
+	name   string
+}
+
+// samplerSummary is a message that had entries suppressed in a window.
+type samplerSummary struct {
+	key        samplerKey
+	suppressed uint64
+	source     *samplerSource
+}
+
+// NewSampler returns a Sampler and starts the goroutine that closes its
+// windows.
+func NewSampler(opts *SamplerOptions) *Sampler {
+	if opts == nil {
+		opts = &SamplerOptions{}
+	}
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	s := &Sampler{
+		stop: make(chan struct{}),
+	}
+
+	s.first = 100
+	if opts.First > 0 {
+		s.first = uint64(opts.First)
+	}
+	if opts.Thereafter > 0 {
+		s.thereafter = uint64(opts.Thereafter)
+	}
+
+	for i := range s.shards {
+		s.shards[i].counters = map[samplerKey]*samplerCounter{}
+	}
+
+	s.wg.Add(1)
+	go s.run(interval)
+
+	return s
+}
+
+// Stop logs the summaries for the current window and stops the background
+// goroutine. Entries logged after Stop are still sampled, but as if they
+// were all in the same window.
+func (s *Sampler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		s.wg.Wait()
+		s.rotate()
+	})
+}
+
+// run closes a window every interval until Stop is called.
+func (s *Sampler) run(interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.rotate()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// allow reports whether an entry should be logged, counting it towards the
+// current window.
+func (s *Sampler) allow(l *intLogger, name string, level Level, msg string) bool {
+	if levelSeverity(level) >= levelSeverity(Panic) {
+		return true
+	}
+
+	key := samplerKey{level: level, msg: msg}
+	shard := &s.shards[samplerHash(key)%samplerShards]
+
+	// The counter is updated with the shard locked, so that rotate can't
+	// reset or drop it in between.
+	shard.mu.RLock()
+	c, ok := shard.counters[key]
+	if ok {
+		defer shard.mu.RUnlock()
+	} else {
+		shard.mu.RUnlock()
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
+
+		c, ok = shard.counters[key]
+		if !ok {
+			c = new(samplerCounter)
+			shard.counters[key] = c
+		}
+	}
+
+	n := atomic.AddUint64(&c.count, 1)
+	if n <= s.first {
+		return true
+	}
+	if s.thereafter > 0 && (n-s.first)%s.thereafter == 0 {
+		return true
+	}
+
+	if atomic.AddUint64(&c.suppressed, 1) == 1 {
+		c.source.Store(&samplerSource{logger: l, name: name})
+	}
+
+	return false
+}
+
+// rotate closes the current window, resetting the counters and logging a
+// summary for each message that had entries suppressed. Counters that saw no
+// entries during the window are dropped, by swapping in a map of those that
+// did.
+func (s *Sampler) rotate() {
+	var summaries []samplerSummary
+
+	for i := range s.shards {
+		shard := &s.shards[i]
+
+		shard.mu.Lock()
+		active := make(map[samplerKey]*samplerCounter, len(shard.counters))
+		for key, c := range shard.counters {
+			count := atomic.SwapUint64(&c.count, 0)
+			suppressed := atomic.SwapUint64(&c.suppressed, 0)
+
+			if suppressed > 0 {
+				if src, ok := c.source.Load().(*samplerSource); ok {
+					summaries = append(summaries, samplerSummary{
+						key:        key,
+						suppressed: suppressed,
+						source:     src,
+					})
+				}
+			}
+
+			if count > 0 {
+				active[key] = c
+			}
+		}
+		shard.counters = active
+		shard.mu.Unlock()
+	}
+
+	for _, sum := range summaries {
+		msg := fmt.Sprintf("suppressed %d similar messages", sum.suppressed)
+		sum.source.logger.writeSummary(sum.source.name, sum.key.level, msg,
+			"sampled_message", sum.key.msg, "suppressed", sum.suppressed)
+	}
+}
+
+// samplerHash returns the FNV-1a hash of key, used to pick its shard.
+func samplerHash(key samplerKey) uint32 {
+	h := uint32(2166136261)
+
+	h ^= uint32(key.level)
+	h *= 16777619
+
+	for i := 0; i < len(key.msg); i++ {
+		h ^= uint32(key.msg[i])
+		h *= 16777619
+	}
+
+	return h
+}
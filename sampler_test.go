@@ -0,0 +1,292 @@
+package hclog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler(t *testing.T) {
+	t.Run("logs the first entries then every nth", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		sampler := NewSampler(&SamplerOptions{
+			Interval:   time.Hour,
+			First:      2,
+			Thereafter: 3,
+		})
+		defer sampler.Stop()
+
+		logger := New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+			Sampler:     sampler,
+		})
+
+		for i := 1; i <= 10; i++ {
+			logger.Error("connection failed", "attempt", i)
+		}
+		logger.Info("connection failed", "attempt", 11)
+
+		assert.Equal(t, strings.Join([]string{
+			"[ERROR] connection failed: attempt=1",
+			"[ERROR] connection failed: attempt=2",
+			"[ERROR] connection failed: attempt=5",
+			"[ERROR] connection failed: attempt=8",
+			"[INFO]  connection failed: attempt=11",
+			"",
+		}, "\n"), buf.String())
+
+		buf.Reset()
+		sampler.rotate()
+
+		assert.Equal(t, "[ERROR] suppressed 6 similar messages: sampled_message=\"connection failed\" suppressed=6\n", buf.String())
+
+		// The window has been reset.
+		buf.Reset()
+		logger.Error("connection failed", "attempt", 12)
+		assert.Equal(t, "[ERROR] connection failed: attempt=12\n", buf.String())
+	})
+
+	t.Run("keeps the summary in json", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		sampler := NewSampler(&SamplerOptions{Interval: time.Hour, First: 1})
+		defer sampler.Stop()
+
+		logger := New(&LoggerOptions{
+			Output:     &buf,
+			JSONFormat: true,
+			Sampler:    sampler,
+		})
+
+		logger.Info("boom")
+		logger.Info("boom")
+		logger.Info("boom")
+
+		buf.Reset()
+		sampler.rotate()
+
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &raw))
+		assert.Equal(t, "suppressed 2 similar messages", raw["message"])
+		assert.Equal(t, "boom", raw["sampled_message"])
+		assert.Equal(t, float64(2), raw["suppressed"])
+	})
+
+	t.Run("ignores excluded entries", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		sampler := NewSampler(&SamplerOptions{Interval: time.Hour, First: 1})
+		defer sampler.Stop()
+
+		logger := New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+			Sampler:     sampler,
+			Exclude: func(level Level, msg string, args ...interface{}) bool {
+				return len(args) == 2 && args[1] == "noisy"
+			},
+		})
+
+		logger.Info("request", "client", "noisy")
+		logger.Info("request", "client", "noisy")
+		logger.Info("request", "client", "quiet")
+
+		assert.Equal(t, "[INFO]  request: client=quiet\n", buf.String())
+	})
+
+	t.Run("is shared by derived loggers", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		sampler := NewSampler(&SamplerOptions{
+			Interval: time.Hour,
+			First:    1,
+		})
+		defer sampler.Stop()
+
+		logger := New(&LoggerOptions{
+			Name:        "app",
+			Output:      &buf,
+			DisableTime: true,
+			Sampler:     sampler,
+		})
+
+		logger.Warn("slow request")
+		sub := logger.Named("http").With("path", "/")
+		sub.Warn("slow request")
+		sub.Warn("slow request")
+
+		buf.Reset()
+		sampler.Stop()
+
+		assert.Equal(t, "[WARN]  app.http: suppressed 2 similar messages: path=/ sampled_message=\"slow request\" suppressed=2\n", buf.String())
+	})
+
+	t.Run("removes idle messages", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		sampler := NewSampler(&SamplerOptions{Interval: time.Hour})
+		defer sampler.Stop()
+
+		logger := New(&LoggerOptions{
+			Output:  &buf,
+			Sampler: sampler,
+		})
+
+		logger.Info("a")
+		logger.Info("b")
+
+		count := func() int {
+			var n int
+			for i := range sampler.shards {
+				n += len(sampler.shards[i].counters)
+			}
+			return n
+		}
+
+		assert.Equal(t, 2, count())
+
+		sampler.rotate()
+		logger.Info("a")
+		assert.Equal(t, 2, count())
+
+		sampler.rotate()
+		assert.Equal(t, 1, count())
+
+		sampler.rotate()
+		assert.Equal(t, 0, count())
+	})
+
+	t.Run("does not sample panics", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		sampler := NewSampler(&SamplerOptions{Interval: time.Hour, First: 1})
+		defer sampler.Stop()
+
+		logger := New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+			Sampler:     sampler,
+		})
+
+		for i := 0; i < 2; i++ {
+			assert.Panics(t, func() { LogPanic(logger, "bad state") })
+		}
+
+		assert.Equal(t, "[PANIC] bad state\n[PANIC] bad state\n", buf.String())
+	})
+
+	t.Run("closes windows in the background", func(t *testing.T) {
+		var buf syncBuffer
+
+		sampler := NewSampler(&SamplerOptions{
+			Interval: 10 * time.Millisecond,
+			First:    1,
+		})
+		defer sampler.Stop()
+
+		logger := New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+			Sampler:     sampler,
+		})
+
+		logger.Error("disk full")
+		logger.Error("disk full")
+
+		require.Eventually(t, func() bool {
+			return strings.Contains(buf.String(), "suppressed 1 similar messages")
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("counts concurrent entries", func(t *testing.T) {
+		var buf syncBuffer
+
+		sampler := NewSampler(&SamplerOptions{
+			Interval:   time.Hour,
+			First:      10,
+			Thereafter: 100,
+		})
+		defer sampler.Stop()
+
+		logger := New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+			Sampler:     sampler,
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					logger.Error("hot loop")
+				}
+			}()
+		}
+		wg.Wait()
+
+		// 10 first, then every 100th of the remaining 7990.
+		assert.Equal(t, 10+79, strings.Count(buf.String(), "[ERROR] hot loop\n"))
+
+		sampler.rotate()
+		assert.Contains(t, buf.String(), "suppressed 7911 similar messages")
+	})
+
+	t.Run("counts entries while windows close", func(t *testing.T) {
+		var buf syncBuffer
+
+		sampler := NewSampler(&SamplerOptions{Interval: time.Hour, First: 1})
+		defer sampler.Stop()
+
+		logger := New(&LoggerOptions{
+			Output:     &buf,
+			JSONFormat: true,
+			Sampler:    sampler,
+		})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 200; i++ {
+				sampler.rotate()
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					logger.Error("hot loop")
+				}
+			}()
+		}
+		wg.Wait()
+		<-done
+		sampler.rotate()
+
+		// Every entry is either logged or counted in a summary.
+		var total int
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var raw map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line), &raw))
+
+			if n, ok := raw["suppressed"].(float64); ok {
+				total += int(n)
+			} else {
+				total++
+			}
+		}
+		assert.Equal(t, 4000, total)
+	})
+}
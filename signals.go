@@ -0,0 +1,185 @@
+package hclog
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// SignalOptions can be used to configure HandleSignals.
+type SignalOptions struct {
+	// Open is called on SIGHUP to open the output again, usually a file that
+	// has been moved away by an external tool like logrotate. The logger is
+	// switched to the returned writer with ResetOutputWithFlush. If Open is
+	// nil, SIGHUP is not handled.
+	Open func() (io.Writer, error)
+
+	// Output is the output the logger is writing to when HandleSignals is
+	// called. When the output is replaced on SIGHUP, the previous output is
+	// flushed first if it is Flushable, and closed afterwards if it is an
+	// io.Closer.
+	Output io.Writer
+
+	// Color is the color option used for the reopened output.
+	Color ColorOption
+}
+
+// HandleSignals installs signal handlers for l. SIGUSR1 steps the level of l
+// down towards Trace, making it more verbose, and SIGUSR2 steps it up towards
+// Fatal, going through the levels in order of severity, including those added
+// with RegisterLevel. Each change is logged at the new level. The level isn't
+// changed if it's set by LevelOverrides. If opts.Open is set, SIGHUP reopens
+// the output of l, which must implement OutputResettable.
+//
+// The returned function removes the handlers, and once it returns no more
+// signals are handled. SIGUSR1 and SIGUSR2 are not available on Windows, so
+// only SIGHUP can be handled there.
+func HandleSignals(l Logger, opts *SignalOptions) (stop func()) {
+	if opts == nil {
+		opts = &SignalOptions{}
+	}
+
+	var sigs []os.Signal
+	if levelDownSignal != nil {
+		sigs = append(sigs, levelDownSignal, levelUpSignal)
+	}
+	if opts.Open != nil {
+		sigs = append(sigs, reopenSignal)
+	}
+
+	h := &signalHandler{
+		logger: l,
+		open:   opts.Open,
+		output: opts.Output,
+		color:  opts.Color,
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	if len(sigs) > 0 {
+		signal.Notify(ch, sigs...)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case sig := <-ch:
+					h.handle(sig)
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+type signalHandler struct {
+	logger Logger
+	open   func() (io.Writer, error)
+	output io.Writer
+	color  ColorOption
+}
+
+func (h *signalHandler) handle(sig os.Signal) {
+	switch sig {
+	case levelDownSignal:
+		h.stepLevel(-1)
+	case levelUpSignal:
+		h.stepLevel(1)
+	case reopenSignal:
+		h.reopen()
+	}
+}
+
+// stepLevel moves the level of the logger to the next less severe level if
+// delta is negative, or the next more severe one otherwise, staying within
+// Trace to Fatal. A logger that is Off is moved to Fatal either way. A
+// logger whose level is set by LevelOverrides is left as it is.
+func (h *signalHandler) stepLevel(delta int) {
+	if o, ok := h.logger.(levelOverrider); ok {
+		if level, overridden := o.overriddenLevel(); overridden {
+			h.logger.Warn("log level not changed by signal, it is set by LevelOverrides", "level", level.String())
+			return
+		}
+	}
+
+	from := currentLevel(h.logger)
+	severity := levelSeverity(from)
+	levels := levelsBySeverity()
+
+	to := from
+	switch {
+	case from == Off:
+		to = levels[len(levels)-1]
+	case delta < 0:
+		for _, level := range levels {
+			if levelSeverity(level) < severity {
+				to = level
+			}
+		}
+	default:
+		for i := len(levels) - 1; i >= 0; i-- {
+			if levelSeverity(levels[i]) > severity {
+				to = levels[i]
+			}
+		}
+	}
+
+	if to == from {
+		return
+	}
+
+	h.logger.SetLevel(to)
+	h.logger.Log(to, "log level changed by signal", "from", from.String(), "to", to.String())
+}
+
+// reopen opens the output again and switches the logger over to it.
+func (h *signalHandler) reopen() {
+	r, ok := h.logger.(OutputResettable)
+	if !ok {
+		h.logger.Error("unable to reopen log output, logger does not support resetting its output")
+		return
+	}
+
+	w, err := h.open()
+	if err != nil {
+		h.logger.Error("unable to reopen log output", "error", err)
+		return
+	}
+
+	flushable, ok := h.output.(Flushable)
+	if !ok {
+		flushable = noopFlushable{}
+	}
+
+	if err := r.ResetOutputWithFlush(&LoggerOptions{Output: w, Color: h.color}, flushable); err != nil {
+		h.logger.Error("unable to reopen log output", "error", err)
+		return
+	}
+
+	if c, ok := h.output.(io.Closer); ok {
+		c.Close()
+	}
+	h.output = w
+
+	h.logger.Info("reopened log output")
+}
+
+// noopFlushable is used when the previous output has nothing to flush.
+type noopFlushable struct{}
+
+func (noopFlushable) Flush() error { return nil }
@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package hclog
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	levelDownSignal os.Signal = syscall.SIGUSR1
+	levelUpSignal   os.Signal = syscall.SIGUSR2
+	reopenSignal    os.Signal = syscall.SIGHUP
+)
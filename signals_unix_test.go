@@ -0,0 +1,229 @@
+//go:build !windows
+// +build !windows
+
+package hclog
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSignals(t *testing.T) {
+	signalSelf := func(t *testing.T, sig syscall.Signal) {
+		require.NoError(t, syscall.Kill(os.Getpid(), sig))
+	}
+
+	t.Run("steps the level", func(t *testing.T) {
+		withTestLevels(t)
+
+		var buf syncBuffer
+
+		logger := New(&LoggerOptions{
+			Level:       Info,
+			Output:      &buf,
+			DisableTime: true,
+		})
+
+		stop := HandleSignals(logger, nil)
+		defer stop()
+
+		// Signals of the same kind can be merged when sent too quickly, so
+		// wait for each one to be handled before sending the next.
+		step := func(sig syscall.Signal, done func() bool) {
+			signalSelf(t, sig)
+			require.Eventually(t, done, 5*time.Second, 5*time.Millisecond)
+		}
+
+		step(syscall.SIGUSR1, logger.IsDebug)
+		step(syscall.SIGUSR1, logger.IsTrace)
+		level := func(want Level) func() bool {
+			return func() bool { return logger.(LevelGetter).GetLevel() == want }
+		}
+
+		step(syscall.SIGUSR2, level(Debug))
+		step(syscall.SIGUSR2, level(Info))
+		step(syscall.SIGUSR2, level(testNotice))
+		step(syscall.SIGUSR2, level(Warn))
+		step(syscall.SIGUSR2, level(Error))
+		assert.True(t, logger.IsError())
+		assert.False(t, logger.IsWarn())
+
+		stop()
+
+		assert.Equal(t, strings.Join([]string{
+			"[DEBUG] log level changed by signal: from=info to=debug",
+			"[TRACE] log level changed by signal: from=debug to=trace",
+			"[DEBUG] log level changed by signal: from=trace to=debug",
+			"[INFO]  log level changed by signal: from=debug to=info",
+			"[NOTICE] log level changed by signal: from=info to=notice",
+			"[WARN]  log level changed by signal: from=notice to=warn",
+			"[ERROR] log level changed by signal: from=warn to=error",
+			"",
+		}, "\n"), buf.String())
+	})
+
+	t.Run("stays within trace to fatal", func(t *testing.T) {
+		// The registered levels are global, so are registered up front to
+		// not depend on the order of the tests.
+		withTestLevels(t)
+
+		var buf syncBuffer
+
+		logger := New(&LoggerOptions{
+			Level:       Trace,
+			Output:      &buf,
+			DisableTime: true,
+		})
+		h := &signalHandler{logger: logger}
+
+		h.stepLevel(-1)
+		assert.True(t, logger.IsTrace())
+
+		logger.SetLevel(Error)
+		h.stepLevel(1)
+		h.stepLevel(1)
+		h.stepLevel(1)
+		h.stepLevel(1)
+		assert.Equal(t, Fatal, logger.(LevelGetter).GetLevel())
+
+		logger.SetLevel(Off)
+		h.stepLevel(1)
+		assert.Equal(t, Fatal, logger.(LevelGetter).GetLevel())
+
+		assert.Equal(t, strings.Join([]string{
+			"[AUDIT] log level changed by signal: from=error to=audit",
+			"[PANIC] log level changed by signal: from=audit to=panic",
+			"[FATAL] log level changed by signal: from=panic to=fatal",
+			"[FATAL] log level changed by signal: from=off to=fatal",
+			"",
+		}, "\n"), buf.String())
+	})
+
+	t.Run("steps through registered levels", func(t *testing.T) {
+		withTestLevels(t)
+
+		var buf syncBuffer
+
+		logger := New(&LoggerOptions{
+			Level:       Info,
+			Output:      &buf,
+			DisableTime: true,
+		})
+		h := &signalHandler{logger: logger}
+
+		h.stepLevel(1)
+		assert.Equal(t, testNotice, logger.(LevelGetter).GetLevel())
+		h.stepLevel(1)
+		assert.Equal(t, Warn, logger.(LevelGetter).GetLevel())
+		h.stepLevel(-1)
+		h.stepLevel(-1)
+		assert.Equal(t, Info, logger.(LevelGetter).GetLevel())
+	})
+
+	t.Run("leaves levels set by LevelOverrides", func(t *testing.T) {
+		overrides, err := ParseLevelOverrides("my-app.raft=debug")
+		require.NoError(t, err)
+
+		var buf syncBuffer
+
+		logger := New(&LoggerOptions{
+			Name:           "my-app",
+			Level:          Info,
+			Output:         &buf,
+			DisableTime:    true,
+			LevelOverrides: overrides,
+		})
+		raft := logger.Named("raft")
+
+		h := &signalHandler{logger: raft}
+		h.stepLevel(1)
+
+		assert.True(t, raft.IsDebug())
+		assert.Equal(t, Info, raft.(LevelGetter).GetLevel())
+		assert.Equal(t, "[WARN]  my-app.raft: log level not changed by signal, it is set by LevelOverrides: level=debug\n", buf.String())
+	})
+
+	t.Run("reopens the output", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "hclog-signals")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		path := filepath.Join(dir, "app.log")
+		open := func() (io.Writer, error) {
+			return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		}
+
+		f, err := open()
+		require.NoError(t, err)
+
+		logger := New(&LoggerOptions{
+			Output:      f,
+			DisableTime: true,
+		})
+
+		stop := HandleSignals(logger, &SignalOptions{
+			Open:   open,
+			Output: f,
+		})
+		defer stop()
+
+		logger.Info("before")
+
+		rotated := path + ".1"
+		require.NoError(t, os.Rename(path, rotated))
+
+		signalSelf(t, syscall.SIGHUP)
+		require.Eventually(t, func() bool {
+			_, err := os.Stat(path)
+			return err == nil
+		}, 5*time.Second, 5*time.Millisecond)
+
+		stop()
+		logger.Info("after")
+
+		data, err := ioutil.ReadFile(rotated)
+		require.NoError(t, err)
+		assert.Equal(t, "[INFO]  before\n", string(data))
+
+		data, err = ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "[INFO]  reopened log output\n[INFO]  after\n", string(data))
+
+		// The previous output was closed.
+		_, err = f.Write([]byte("closed"))
+		assert.Error(t, err)
+	})
+
+	t.Run("stops handling signals", func(t *testing.T) {
+		var buf syncBuffer
+
+		logger := New(&LoggerOptions{
+			Level:  Info,
+			Output: &buf,
+		})
+
+		stop := HandleSignals(logger, nil)
+		stop()
+		stop()
+
+		// Keep the signal from killing the test process now that the
+		// handler is gone.
+		guard := HandleSignals(New(&LoggerOptions{Output: ioutil.Discard}), nil)
+		defer guard()
+
+		signalSelf(t, syscall.SIGUSR1)
+		time.Sleep(50 * time.Millisecond)
+
+		assert.False(t, logger.IsDebug())
+		assert.Empty(t, buf.String())
+	})
+}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package hclog
+
+import (
+	"os"
+	"syscall"
+)
+
+// Windows has no SIGUSR1 or SIGUSR2, so the level can't be changed by signal.
+var (
+	levelDownSignal os.Signal
+	levelUpSignal   os.Signal
+	reopenSignal    os.Signal = syscall.SIGHUP
+)
@@ -0,0 +1,489 @@
+//go:build go1.21
+// +build go1.21
+
+package hclog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"math"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Make sure that slogHandler is a slog.Handler
+var _ slog.Handler = &slogHandler{}
+
+// slogHandler is a log/slog Handler that emits records through a Logger.
+type slogHandler struct {
+	logger Logger
+
+	// prefix is prepended to the keys of all attributes, and is built up
+	// from the names given to WithGroup separated by dots.
+	prefix string
+}
+
+// NewSlogHandler returns a log/slog Handler that sends records through the
+// given Logger, so that code using log/slog produces the same output as code
+// using the Logger directly. Record levels are mapped to the nearest Level,
+// WithAttrs is implemented with Logger.With and groups opened by WithGroup
+// become dotted key prefixes, like "request.id".
+//
+// When l was created by this package with IncludeLocation set, the location
+// of the log/slog call is taken from the Record rather than from the stack.
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// Enabled reports whether the Logger would emit records at the given level.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return IsLevelEnabled(h.logger, levelFromSlog(level))
+}
+
+// Handle emits the record through the Logger.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	args := make([]interface{}, 0, r.NumAttrs()*2)
+	args = append(args, ContextArgs(ctx)...)
+	r.Attrs(func(a slog.Attr) bool {
+		args = appendSlogAttr(args, h.prefix, a)
+		return true
+	})
+
+	level := levelFromSlog(r.Level)
+	if el, ok := h.logger.(entryLogger); ok {
+		el.logEntry(h.logger.Name(), level, r.Message, r.PC, args...)
+	} else {
+		h.logger.Log(level, r.Message, args...)
+	}
+
+	return nil
+}
+
+// WithAttrs returns a Handler whose Logger always includes the given
+// attributes.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var args []interface{}
+	for _, a := range attrs {
+		args = appendSlogAttr(args, h.prefix, a)
+	}
+	if len(args) == 0 {
+		return h
+	}
+
+	return &slogHandler{
+		logger: h.logger.With(args...),
+		prefix: h.prefix,
+	}
+}
+
+// WithGroup returns a Handler that prefixes the keys of all further
+// attributes with the group name.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &slogHandler{
+		logger: h.logger,
+		prefix: h.prefix + name + ".",
+	}
+}
+
+// appendSlogAttr flattens a into key/value pairs and appends them to args.
+func appendSlogAttr(args []interface{}, prefix string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+
+	// Attrs that are entirely empty are to be ignored by handlers
+	if a.Equal(slog.Attr{}) {
+		return args
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			args = appendSlogAttr(args, prefix, ga)
+		}
+		return args
+	}
+
+	return append(args, prefix+a.Key, a.Value.Any())
+}
+
+// levelFromSlog maps a log/slog level to the closest Level at or below it.
+func levelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return Trace
+	case level < slog.LevelInfo:
+		return Debug
+	case level < slog.LevelWarn:
+		return Info
+	case level < slog.LevelError:
+		return Warn
+	default:
+		return Error
+	}
+}
+
+// slogAnchors pairs the severities of the built-in levels with the log/slog
+// levels they map to, in order of severity.
+var slogAnchors = []struct {
+	severity int32
+	level    slog.Level
+}{
+	{100, slog.LevelDebug - 4},
+	{200, slog.LevelDebug},
+	{300, slog.LevelInfo},
+	{400, slog.LevelWarn},
+	{500, slog.LevelError},
+	{600, slog.LevelError + 2},
+	{700, slog.LevelError + 4},
+}
+
+// levelToSlog maps a Level to the equivalent log/slog level. Registered
+// levels are placed between the built-in levels either side of them.
+func levelToSlog(level Level) slog.Level {
+	switch level {
+	case NoLevel:
+		return slog.Level(math.MinInt32)
+	case Off:
+		return slog.Level(math.MaxInt32)
+	}
+
+	severity := levelSeverity(level)
+
+	first, last := slogAnchors[0], slogAnchors[len(slogAnchors)-1]
+	switch {
+	case severity <= first.severity:
+		return first.level
+	case severity >= last.severity:
+		return last.level
+	}
+
+	for i := 1; i < len(slogAnchors); i++ {
+		lo, hi := slogAnchors[i-1], slogAnchors[i]
+		if severity <= hi.severity {
+			offset := int64(severity-lo.severity) * int64(hi.level-lo.level) / int64(hi.severity-lo.severity)
+			return lo.level + slog.Level(offset)
+		}
+	}
+
+	return last.level
+}
+
+// Make sure that slogLogger is a Logger
+var _ Logger = &slogLogger{}
+var _ FatalLogger = &slogLogger{}
+var _ LevelGetter = &slogLogger{}
+
+// slogLogger is a Logger that sends entries to a log/slog Handler.
+type slogLogger struct {
+	handler      slog.Handler
+	name         string
+	callerOffset int
+
+	// This is a pointer so that it's shared by any derived loggers, unless
+	// independentLevels is set.
+	level *slogLevelVar
+
+	implied []interface{}
+
+	exclude func(level Level, msg string, args ...interface{}) bool
+
+	// create subloggers with their own level setting
+	independentLevels bool
+}
+
+// FromSlogHandler returns a Logger that sends its entries to the given
+// log/slog Handler. This allows libraries that accept a Logger to write into
+// an application that has standardized on log/slog.
+//
+// The name of the logger is added to each record as the "module" attribute
+// and key/value pairs given to With are added to the Handler with WithAttrs.
+// Of the options only Name, Level, Exclude, IndependentLevels and
+// AdditionalLocationOffset are used; output and formatting are up to the
+// Handler.
+func FromSlogHandler(h slog.Handler, opts *LoggerOptions) Logger {
+	if opts == nil {
+		opts = &LoggerOptions{}
+	}
+
+	level := opts.Level
+	if level == NoLevel {
+		level = DefaultLevel
+	}
+
+	l := &slogLogger{
+		handler:           h,
+		name:              opts.Name,
+		callerOffset:      offsetSlogLogger + opts.AdditionalLocationOffset,
+		level:             new(slogLevelVar),
+		exclude:           opts.Exclude,
+		independentLevels: opts.IndependentLevels,
+	}
+	l.level.set(level)
+
+	return l
+}
+
+// offsetSlogLogger is the number of stack frames to skip, counting
+// runtime.Callers itself, to get to the caller of one of the Warn, Info, Log,
+// etc methods.
+const offsetSlogLogger = 3
+
+// log sends the entry to the Handler if the level is enabled. All the
+// public methods call it directly so that the caller is always the same
+// number of frames away.
+func (l *slogLogger) log(level Level, msg string, args ...interface{}) {
+	slevel := levelToSlog(level)
+	if !l.enabled(slevel) {
+		return
+	}
+
+	if l.exclude != nil && l.exclude(level, msg, args...) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(l.callerOffset, pcs[:])
+
+	r := slog.NewRecord(time.Now(), slevel, msg, pcs[0])
+	if l.name != "" {
+		r.AddAttrs(slog.String("module", l.name))
+	}
+	r.AddAttrs(slogAttrs(args)...)
+
+	_ = l.handler.Handle(context.Background(), r)
+}
+
+func (l *slogLogger) enabled(level slog.Level) bool {
+	return level >= l.level.Level() && l.handler.Enabled(context.Background(), level)
+}
+
+// slogAttrs converts key/value pairs to attributes, following the same rules
+// as the other Loggers for a trailing stacktrace or value without a key.
+func slogAttrs(args []interface{}) []slog.Attr {
+	if len(args)%2 != 0 {
+		if cs, ok := args[len(args)-1].(CapturedStacktrace); ok {
+			args = append(args[:len(args)-1:len(args)-1], "stacktrace", cs)
+		} else {
+			extra := args[len(args)-1]
+			args = append(args[:len(args)-1:len(args)-1], MissingKey, extra)
+		}
+	}
+
+	attrs := make([]slog.Attr, 0, len(args)/2)
+	for i := 0; i < len(args); i = i + 2 {
+		var key string
+
+		switch st := args[i].(type) {
+		case string:
+			key = st
+		default:
+			key = fmt.Sprintf("%s", st)
+		}
+
+		switch sv := args[i+1].(type) {
+		case Format:
+			attrs = append(attrs, slog.String(key, fmt.Sprintf(sv[0].(string), sv[1:]...)))
+		case CapturedStacktrace:
+			attrs = append(attrs, slog.String(key, string(sv)))
+		default:
+			attrs = append(attrs, slog.Any(key, sv))
+		}
+	}
+
+	return attrs
+}
+
+// Emit the message and args at the provided level
+func (l *slogLogger) Log(level Level, msg string, args ...interface{}) {
+	l.log(level, msg, args...)
+}
+
+// Emit the message and args at TRACE level
+func (l *slogLogger) Trace(msg string, args ...interface{}) {
+	l.log(Trace, msg, args...)
+}
+
+// Emit the message and args at DEBUG level
+func (l *slogLogger) Debug(msg string, args ...interface{}) {
+	l.log(Debug, msg, args...)
+}
+
+// Emit the message and args at INFO level
+func (l *slogLogger) Info(msg string, args ...interface{}) {
+	l.log(Info, msg, args...)
+}
+
+// Emit the message and args at WARN level
+func (l *slogLogger) Warn(msg string, args ...interface{}) {
+	l.log(Warn, msg, args...)
+}
+
+// Emit the message and args at ERROR level
+func (l *slogLogger) Error(msg string, args ...interface{}) {
+	l.log(Error, msg, args...)
+}
+
+// Emit the message and args at FATAL level, then exit
+func (l *slogLogger) Fatal(msg string, args ...interface{}) {
+	l.log(Fatal, msg, args...)
+	exitFunc(1)
+}
+
+// Emit the message and args at PANIC level, then panic
+func (l *slogLogger) Panic(msg string, args ...interface{}) {
+	l.log(Panic, msg, args...)
+	panic(msg)
+}
+
+// Indicate that the logger would emit TRACE level logs
+func (l *slogLogger) IsTrace() bool {
+	return l.enabled(levelToSlog(Trace))
+}
+
+// Indicate that the logger would emit DEBUG level logs
+func (l *slogLogger) IsDebug() bool {
+	return l.enabled(levelToSlog(Debug))
+}
+
+// Indicate that the logger would emit INFO level logs
+func (l *slogLogger) IsInfo() bool {
+	return l.enabled(levelToSlog(Info))
+}
+
+// Indicate that the logger would emit WARN level logs
+func (l *slogLogger) IsWarn() bool {
+	return l.enabled(levelToSlog(Warn))
+}
+
+// Indicate that the logger would emit ERROR level logs
+func (l *slogLogger) IsError() bool {
+	return l.enabled(levelToSlog(Error))
+}
+
+// ImpliedArgs returns the loggers implied args
+func (l *slogLogger) ImpliedArgs() []interface{} {
+	return l.implied
+}
+
+// Return a sub-Logger for which every emitted log message will contain
+// the given key/value pairs. The pairs are added to the Handler with
+// WithAttrs.
+func (l *slogLogger) With(args ...interface{}) Logger {
+	if len(args) == 0 {
+		return l
+	}
+
+	attrs := slogAttrs(args)
+
+	sl := l.copy()
+	sl.handler = l.handler.WithAttrs(attrs)
+	sl.implied = make([]interface{}, 0, len(l.implied)+len(attrs)*2)
+	sl.implied = append(sl.implied, l.implied...)
+	for _, a := range attrs {
+		sl.implied = append(sl.implied, a.Key, a.Value.Any())
+	}
+
+	return sl
+}
+
+// Name returns the loggers name
+func (l *slogLogger) Name() string {
+	return l.name
+}
+
+// Create a new sub-Logger that a name decending from the current name.
+// This is used to create a subsystem specific Logger.
+func (l *slogLogger) Named(name string) Logger {
+	sl := l.copy()
+
+	if sl.name != "" {
+		sl.name = sl.name + "." + name
+	} else {
+		sl.name = name
+	}
+
+	return sl
+}
+
+// Create a new sub-Logger with an explicit name. This ignores the current
+// name. This is used to create a standalone logger that doesn't fall
+// within the normal hierarchy.
+func (l *slogLogger) ResetNamed(name string) Logger {
+	sl := l.copy()
+
+	sl.name = name
+
+	return sl
+}
+
+// Update the logging level on-the-fly. This will affect all subloggers as
+// well, unless they were created with IndependentLevels.
+func (l *slogLogger) SetLevel(level Level) {
+	l.level.set(level)
+}
+
+// Returns the level set with SetLevel, or the one the logger was created
+// with
+func (l *slogLogger) GetLevel() Level {
+	return Level(atomic.LoadInt32(&l.level.level))
+}
+
+// slogLevelVar holds the level of a slogLogger, both as set and mapped to
+// the log/slog level that entries are compared against.
+type slogLevelVar struct {
+	slog.LevelVar
+	level int32
+}
+
+func (v *slogLevelVar) set(level Level) {
+	atomic.StoreInt32(&v.level, int32(level))
+	v.Set(levelToSlog(level))
+}
+
+// Create a *log.Logger that will send it's data through this Logger. This
+// allows packages that expect to be using the standard library log to actually
+// use this logger.
+func (l *slogLogger) StandardLogger(opts *StandardLoggerOptions) *log.Logger {
+	if opts == nil {
+		opts = &StandardLoggerOptions{}
+	}
+
+	return log.New(l.StandardWriter(opts), "", 0)
+}
+
+func (l *slogLogger) StandardWriter(opts *StandardLoggerOptions) io.Writer {
+	newLog := *l
+	// the stack is
+	// logger.printf() -> l.Output() ->l.out.writer(hclog:stdlogAdaptor.write) -> hclog:stdlogAdaptor.dispatch()
+	// So plus 4.
+	newLog.callerOffset = l.callerOffset + 4
+
+	return &stdlogAdapter{
+		log:                      &newLog,
+		inferLevels:              opts.InferLevels,
+		inferLevelsWithTimestamp: opts.InferLevelsWithTimestamp,
+		forceLevel:               opts.ForceLevel,
+	}
+}
+
+// copy returns a shallow copy of the slogLogger, replacing the level var
+// when necessary
+func (l *slogLogger) copy() *slogLogger {
+	sl := *l
+
+	if l.independentLevels {
+		sl.level = new(slogLevelVar)
+		sl.level.set(l.GetLevel())
+	}
+
+	return &sl
+}
@@ -0,0 +1,399 @@
+//go:build go1.21
+// +build go1.21
+
+package hclog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogHandler(t *testing.T) {
+	// newPair returns a hclog Logger and a slog Logger backed by an identical
+	// hclog Logger, each writing to their own buffer.
+	newPair := func(opts LoggerOptions) (Logger, *bytes.Buffer, *slog.Logger, *bytes.Buffer) {
+		var hbuf, sbuf bytes.Buffer
+
+		opts.Output = &hbuf
+		hl := New(&opts)
+
+		opts.Output = &sbuf
+		sl := slog.New(NewSlogHandler(New(&opts)))
+
+		return hl, &hbuf, sl, &sbuf
+	}
+
+	formats := []struct {
+		name string
+		json bool
+	}{
+		{"plain", false},
+		{"json", true},
+	}
+
+	for _, f := range formats {
+		t.Run(f.name+" matches hclog output", func(t *testing.T) {
+			hl, hbuf, sl, sbuf := newPair(LoggerOptions{
+				Name:        "test",
+				JSONFormat:  f.json,
+				DisableTime: true,
+			})
+
+			hl.Info("this is test", "who", "programmer", "count", 3, "took", time.Second)
+			sl.Info("this is test", "who", "programmer", "count", 3, "took", time.Second)
+
+			assert.Equal(t, hbuf.String(), sbuf.String())
+		})
+
+		t.Run(f.name+" maps WithAttrs to With", func(t *testing.T) {
+			hl, hbuf, sl, sbuf := newPair(LoggerOptions{
+				Name:        "test",
+				JSONFormat:  f.json,
+				DisableTime: true,
+			})
+
+			hl.With("request", "abc", "attempt", 2).Warn("retrying", "error", errors.New("timeout"))
+			sl.With("request", "abc", "attempt", 2).Warn("retrying", "error", errors.New("timeout"))
+
+			assert.Equal(t, hbuf.String(), sbuf.String())
+		})
+
+		t.Run(f.name+" maps groups to dotted keys", func(t *testing.T) {
+			hl, hbuf, sl, sbuf := newPair(LoggerOptions{
+				Name:        "test",
+				JSONFormat:  f.json,
+				DisableTime: true,
+			})
+
+			hl.With("req.id", "abc").Error("failed", "req.peer.addr", "10.0.0.1", "req.peer.port", 8080, "req.code", 7)
+			sl.WithGroup("req").With("id", "abc").Error("failed",
+				slog.Group("peer", "addr", "10.0.0.1", "port", 8080),
+				slog.Group("", "code", 7),
+			)
+
+			assert.Equal(t, hbuf.String(), sbuf.String())
+		})
+	}
+
+	t.Run("maps levels", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		l := New(&LoggerOptions{
+			Level:       Trace,
+			Output:      &buf,
+			DisableTime: true,
+		})
+
+		sl := slog.New(NewSlogHandler(l))
+
+		cases := []struct {
+			level  slog.Level
+			expect string
+		}{
+			{slog.LevelDebug - 4, "[TRACE]"},
+			{slog.LevelDebug, "[DEBUG]"},
+			{slog.LevelInfo, "[INFO] "},
+			{slog.LevelInfo + 2, "[INFO] "},
+			{slog.LevelWarn, "[WARN] "},
+			{slog.LevelError, "[ERROR]"},
+			{slog.LevelError + 4, "[ERROR]"},
+		}
+
+		for _, c := range cases {
+			buf.Reset()
+			sl.Log(context.Background(), c.level, "level test")
+			assert.Equal(t, c.expect+" level test\n", buf.String(), c.level.String())
+		}
+	})
+
+	t.Run("respects the logger level", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		l := New(&LoggerOptions{
+			Level:  Warn,
+			Output: &buf,
+		})
+
+		h := NewSlogHandler(l)
+		assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+		assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+
+		slog.New(h).Info("not shown")
+		assert.Empty(t, buf.String())
+
+		l.SetLevel(Info)
+		assert.True(t, h.Enabled(context.Background(), slog.LevelInfo))
+	})
+
+	t.Run("uses the record location", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		l := New(&LoggerOptions{
+			Name:            "test",
+			Output:          &buf,
+			DisableTime:     true,
+			IncludeLocation: true,
+		})
+
+		slog.New(NewSlogHandler(l)).Info("this is test", "who", "programmer")
+		_, file, line, _ := runtime.Caller(0)
+
+		expected := fmt.Sprintf("[INFO]  %s:%d: test: this is test: who=programmer\n", trimCallerPath(file), line-1)
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("uses the record location in json", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		l := New(&LoggerOptions{
+			Name:            "test",
+			Output:          &buf,
+			JSONFormat:      true,
+			IncludeLocation: true,
+		})
+
+		slog.New(NewSlogHandler(l)).Info("this is test")
+		_, file, line, _ := runtime.Caller(0)
+
+		assert.Contains(t, buf.String(), fmt.Sprintf(`"caller":"%s:%d"`, file, line-1))
+	})
+
+	t.Run("sends records to intercept logger sinks", func(t *testing.T) {
+		var buf, sbuf bytes.Buffer
+
+		l := NewInterceptLogger(&LoggerOptions{
+			Name:        "test",
+			Output:      &buf,
+			DisableTime: true,
+		})
+
+		l.RegisterSink(NewSinkAdapter(&LoggerOptions{
+			Output:      &sbuf,
+			DisableTime: true,
+		}))
+
+		slog.New(NewSlogHandler(l)).With("who", "programmer").Info("this is test")
+
+		expected := "[INFO]  test: this is test: who=programmer\n"
+		assert.Equal(t, expected, buf.String())
+		assert.Equal(t, expected, sbuf.String())
+	})
+
+	t.Run("works with other Logger implementations", func(t *testing.T) {
+		h := NewSlogHandler(NewNullLogger())
+		require.False(t, h.Enabled(context.Background(), slog.LevelError))
+		require.NoError(t, h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "dropped", 0)))
+	})
+}
+
+func TestSlogHandler_ContextExtractors(t *testing.T) {
+	withTestContextExtractors()
+
+	var buf bytes.Buffer
+
+	sl := slog.New(NewSlogHandler(New(&LoggerOptions{
+		Output:      &buf,
+		DisableTime: true,
+	})))
+
+	ctx := context.WithValue(context.Background(), testRequestIDKey{}, "req-1")
+	sl.InfoContext(ctx, "handled", "status", 200)
+	sl.Info("no context")
+
+	assert.Equal(t, "[INFO]  handled: request_id=req-1 status=200\n[INFO]  no context\n", buf.String())
+}
+
+func TestFromSlogHandler(t *testing.T) {
+	// newTextHandler returns a slog.TextHandler without the time, so that the
+	// output is predictable.
+	newTextHandler := func(buf *bytes.Buffer, opts *slog.HandlerOptions) slog.Handler {
+		if opts == nil {
+			opts = &slog.HandlerOptions{}
+		}
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		}
+		return slog.NewTextHandler(buf, opts)
+	}
+
+	t.Run("formats log entries", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := FromSlogHandler(newTextHandler(&buf, nil), &LoggerOptions{
+			Name: "test",
+		})
+
+		logger.Info("this is test", "who", "programmer", "why", "testing")
+
+		assert.Equal(t, "level=INFO msg=\"this is test\" module=test who=programmer why=testing\n", buf.String())
+	})
+
+	t.Run("uses the name as the module attribute", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := FromSlogHandler(newTextHandler(&buf, nil), nil)
+
+		logger.Info("no name")
+		logger.Named("sub").Named("system").Warn("named")
+		logger.Named("sub").ResetNamed("other").Error("reset")
+
+		expected := "level=INFO msg=\"no name\"\n" +
+			"level=WARN msg=named module=sub.system\n" +
+			"level=ERROR msg=reset module=other\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("adds With args as attributes", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := FromSlogHandler(newTextHandler(&buf, nil), &LoggerOptions{
+			Name: "test",
+		})
+
+		derived := logger.With("request", "abc").With("attempt", 2)
+		derived.Info("retrying", "bandwidth", Fmt("%d GB/s", 200))
+
+		assert.Equal(t, []interface{}{"request", "abc", "attempt", int64(2)}, derived.ImpliedArgs())
+		assert.Equal(t, "level=INFO msg=retrying request=abc attempt=2 module=test bandwidth=\"200 GB/s\"\n", buf.String())
+	})
+
+	t.Run("handles a value without a key", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := FromSlogHandler(newTextHandler(&buf, nil), nil)
+
+		logger.Info("unpaired", "who", "programmer", "extra")
+
+		assert.Equal(t, "level=INFO msg=unpaired who=programmer EXTRA_VALUE_AT_END=extra\n", buf.String())
+	})
+
+	t.Run("maps levels", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := FromSlogHandler(newTextHandler(&buf, &slog.HandlerOptions{Level: slog.Level(-100)}), &LoggerOptions{
+			Level: Trace,
+		})
+
+		logger.Trace("trace")
+		logger.Debug("debug")
+		logger.Log(Info, "info")
+		logger.Warn("warn")
+		logger.Error("error")
+
+		expected := "level=DEBUG-4 msg=trace\n" +
+			"level=DEBUG msg=debug\n" +
+			"level=INFO msg=info\n" +
+			"level=WARN msg=warn\n" +
+			"level=ERROR msg=error\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("honors SetLevel", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := FromSlogHandler(newTextHandler(&buf, &slog.HandlerOptions{Level: slog.Level(-100)}), &LoggerOptions{
+			Level: Warn,
+		})
+		sub := logger.Named("sub")
+
+		logger.Info("hidden")
+		assert.False(t, logger.IsInfo())
+		assert.True(t, logger.IsWarn())
+
+		logger.SetLevel(Debug)
+		assert.True(t, sub.IsDebug())
+		assert.False(t, sub.IsTrace())
+		sub.Debug("shown")
+
+		logger.SetLevel(Off)
+		logger.Error("hidden")
+		assert.False(t, logger.IsError())
+
+		assert.Equal(t, "level=DEBUG msg=shown module=sub\n", buf.String())
+	})
+
+	t.Run("supports independent levels", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := FromSlogHandler(newTextHandler(&buf, nil), &LoggerOptions{
+			Level:             Warn,
+			IndependentLevels: true,
+		})
+		sub := logger.Named("sub")
+
+		sub.SetLevel(Info)
+		assert.False(t, logger.IsInfo())
+		assert.True(t, sub.IsInfo())
+
+		sub.SetLevel(Fatal)
+		assert.Equal(t, Fatal, sub.(LevelGetter).GetLevel())
+		assert.Equal(t, Warn, logger.(LevelGetter).GetLevel())
+		assert.Equal(t, Fatal, sub.Named("child").(LevelGetter).GetLevel())
+	})
+
+	t.Run("respects the handler level", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := FromSlogHandler(newTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError}), &LoggerOptions{
+			Level: Trace,
+		})
+
+		assert.False(t, logger.IsWarn())
+		logger.Warn("hidden")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("includes the caller location", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := FromSlogHandler(newTextHandler(&buf, &slog.HandlerOptions{AddSource: true}), nil)
+
+		logger.Info("this is test")
+		_, file, line, _ := runtime.Caller(0)
+
+		assert.Contains(t, buf.String(), fmt.Sprintf("source=%s:%d ", file, line-1))
+	})
+
+	t.Run("supports the standard logger", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := FromSlogHandler(newTextHandler(&buf, &slog.HandlerOptions{AddSource: true}), &LoggerOptions{
+			Name: "test",
+		})
+
+		sl := logger.StandardLogger(&StandardLoggerOptions{InferLevels: true})
+		sl.Printf("[WARN] this is a %s", "test")
+		_, file, line, _ := runtime.Caller(0)
+
+		assert.Contains(t, buf.String(), fmt.Sprintf("source=%s:%d ", file, line-1))
+		assert.Contains(t, buf.String(), "msg=\"this is a test\" module=test\n")
+		assert.True(t, strings.HasPrefix(buf.String(), "level=WARN "))
+	})
+
+	t.Run("round trips through NewSlogHandler", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		inner := New(&LoggerOptions{
+			Output:      &buf,
+			DisableTime: true,
+		})
+
+		logger := FromSlogHandler(NewSlogHandler(inner), &LoggerOptions{Name: "test"})
+		logger.Info("this is test", "who", "programmer")
+
+		assert.Equal(t, "[INFO]  this is test: module=test who=programmer\n", buf.String())
+	})
+}
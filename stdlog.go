@@ -59,8 +59,15 @@ func (s *stdlogAdapter) dispatch(str string, level Level) {
 		s.log.Warn(str)
 	case Error:
 		s.log.Error(str)
+	case Fatal, Panic:
+		// Log rather than Fatal or Panic, so that the program keeps going.
+		s.log.Log(level, str)
 	default:
-		s.log.Info(str)
+		if _, ok := loadCustomLevels()[level]; ok {
+			s.log.Log(level, str)
+		} else {
+			s.log.Info(str)
+		}
 	}
 }
 
@@ -79,6 +86,14 @@ func (s *stdlogAdapter) pickLevel(str string) (Level, string) {
 		return Error, strings.TrimSpace(str[7:])
 	case strings.HasPrefix(str, "[ERR]"):
 		return Error, strings.TrimSpace(str[5:])
+	case strings.HasPrefix(str, "["):
+		// Fatal, Panic and registered levels
+		for bracket, level := range levelBrackets() {
+			if strings.HasPrefix(str, bracket) {
+				return level, strings.TrimSpace(str[len(bracket):])
+			}
+		}
+		return Info, str
 	default:
 		return Info, str
 	}
@@ -0,0 +1,416 @@
+package hclog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// errSyslogWriterClosed is returned when writing to a closed SyslogWriter
+var errSyslogWriterClosed = errors.New("syslog writer is closed")
+
+// errSyslogDisconnected is returned when writing to a SyslogWriter that is
+// waiting to reconnect
+var errSyslogDisconnected = errors.New("syslog writer is disconnected")
+
+// SyslogFacility is the syslog facility that messages are sent with.
+type SyslogFacility int
+
+const (
+	SyslogUser   SyslogFacility = 1
+	SyslogDaemon SyslogFacility = 3
+	SyslogAuth   SyslogFacility = 4
+	SyslogLocal0 SyslogFacility = 16
+	SyslogLocal1 SyslogFacility = 17
+	SyslogLocal2 SyslogFacility = 18
+	SyslogLocal3 SyslogFacility = 19
+	SyslogLocal4 SyslogFacility = 20
+	SyslogLocal5 SyslogFacility = 21
+	SyslogLocal6 SyslogFacility = 22
+	SyslogLocal7 SyslogFacility = 23
+)
+
+// syslogTimeFormat is the RFC 5424 timestamp, which allows at most
+// microsecond precision.
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// SyslogOptions can be used to configure a SyslogWriter.
+type SyslogOptions struct {
+	// The network to send messages over, one of "udp", "tcp", "tls", "unix"
+	// or "unixgram". Defaults to "unixgram".
+	Network string
+
+	// The address of the syslog server. For "unix" and "unixgram" this is
+	// the path of the socket. Defaults to /dev/log.
+	Address string
+
+	// The TLS configuration used for the "tls" network.
+	TLSConfig *tls.Config
+
+	// The facility to send messages with. Defaults to SyslogUser, as the
+	// kernel facility (0) can't be used by user processes.
+	Facility SyslogFacility
+
+	// The APP-NAME sent with each message. If not set, the name of the
+	// logger is used, falling back to the name of the program for loggers
+	// without a name. If set, the name of the logger is sent in the
+	// structured data instead.
+	AppName string
+
+	// The SD-ID of the structured data element holding the name of the
+	// logger when AppName is set. Defaults to "hclog@32473".
+	StructuredDataID string
+
+	// The HOSTNAME sent with each message. Defaults to os.Hostname.
+	Hostname string
+
+	// The timeout for connecting to the server. Defaults to 10 seconds.
+	DialTimeout time.Duration
+
+	// How long to wait before reconnecting after a message couldn't be
+	// sent. The wait is doubled after each failed attempt, up to a minute.
+	// Defaults to one second.
+	ReconnectInterval time.Duration
+}
+
+// SyslogWriter is an io.Writer that sends each write as an RFC 5424 message
+// to a syslog server. It implements LevelWriter and NamedLevelWriter, so when
+// used as the Output for a Logger the level of each message is sent as the
+// syslog severity and the name of the logger as the APP-NAME.
+//
+// Messages sent over a stream ("tcp", "tls" or "unix") use octet-counting
+// framing, so multi-line messages are kept together. If a message can't be
+// sent, the connection is reopened in the background, so that logging isn't
+// held up while the server is down. Messages written in the meantime are
+// dropped, and counted by Dropped.
+//
+// The message already carries a timestamp, so it's usually best to set
+// DisableTime on the Logger.
+type SyslogWriter struct {
+	network  string
+	address  string
+	tls      *tls.Config
+	timeout  time.Duration
+	facility SyslogFacility
+	appName  string
+	sdID     string
+	hostname string
+	procID   string
+	now      func() time.Time
+
+	reconnectInterval time.Duration
+
+	mu           sync.Mutex
+	conn         net.Conn
+	buf          bytes.Buffer
+	closed       bool
+	reconnecting bool
+	dropped      uint64
+
+	// done is closed by Close, to stop reconnecting.
+	done chan struct{}
+}
+
+var (
+	_ LevelWriter      = &SyslogWriter{}
+	_ NamedLevelWriter = &SyslogWriter{}
+)
+
+// NewSyslogWriter returns a SyslogWriter connected to the configured syslog
+// server.
+func NewSyslogWriter(opts *SyslogOptions) (*SyslogWriter, error) {
+	if opts == nil {
+		opts = &SyslogOptions{}
+	}
+
+	w := &SyslogWriter{
+		network:  opts.Network,
+		address:  opts.Address,
+		tls:      opts.TLSConfig,
+		timeout:  opts.DialTimeout,
+		facility: opts.Facility,
+		appName:  opts.AppName,
+		sdID:     opts.StructuredDataID,
+		hostname: opts.Hostname,
+		procID:   strconv.Itoa(os.Getpid()),
+		now:      time.Now,
+
+		reconnectInterval: opts.ReconnectInterval,
+		done:              make(chan struct{}),
+	}
+
+	if w.network == "" {
+		w.network = "unixgram"
+	}
+	if w.address == "" {
+		w.address = "/dev/log"
+	}
+	if w.timeout <= 0 {
+		w.timeout = 10 * time.Second
+	}
+	if w.reconnectInterval <= 0 {
+		w.reconnectInterval = time.Second
+	}
+	if w.facility == 0 {
+		w.facility = SyslogUser
+	}
+	if w.sdID == "" {
+		w.sdID = "hclog@32473"
+	}
+	if w.hostname == "" {
+		w.hostname, _ = os.Hostname()
+	}
+
+	switch w.network {
+	case "udp", "tcp", "tls", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s", w.network)
+	}
+
+	conn, err := w.dial()
+	if err != nil {
+		return nil, err
+	}
+	w.conn = conn
+
+	return w, nil
+}
+
+// Write sends p at the informational severity.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	return w.NamedLevelWrite("", NoLevel, p)
+}
+
+// LevelWrite sends p at the severity matching level.
+func (w *SyslogWriter) LevelWrite(level Level, p []byte) (int, error) {
+	return w.NamedLevelWrite("", level, p)
+}
+
+// NamedLevelWrite sends p at the severity matching level, for the named
+// logger.
+func (w *SyslogWriter) NamedLevelWrite(name string, level Level, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errSyslogWriterClosed
+	}
+
+	w.buf.Reset()
+	w.format(&w.buf, name, level, p)
+
+	msg := w.buf.Bytes()
+	if w.isStream() {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+
+	if w.conn == nil {
+		w.dropped++
+		return 0, errSyslogDisconnected
+	}
+
+	if _, err := w.conn.Write(msg); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		w.dropped++
+		w.startReconnect()
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Dropped returns the number of messages dropped because they couldn't be
+// sent.
+func (w *SyslogWriter) Dropped() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.dropped
+}
+
+// Close closes the connection to the syslog server. Writes after Close return
+// an error.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+	close(w.done)
+
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// format writes the RFC 5424 message for p to buf.
+func (w *SyslogWriter) format(buf *bytes.Buffer, name string, level Level, p []byte) {
+	appName := w.appName
+	if appName == "" {
+		appName = name
+	}
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+
+	fmt.Fprintf(buf, "<%d>1 %s %s %s %s - ",
+		int(w.facility)*8+syslogSeverity(level),
+		w.now().Format(syslogTimeFormat),
+		syslogHeaderField(w.hostname, 255),
+		syslogHeaderField(appName, 48),
+		w.procID,
+	)
+
+	if w.appName != "" && name != "" {
+		buf.WriteString("[")
+		buf.WriteString(w.sdID)
+		buf.WriteString(` module="`)
+		writeSyslogParamValue(buf, name)
+		buf.WriteString(`"]`)
+	} else {
+		buf.WriteString("-")
+	}
+
+	buf.WriteByte(' ')
+	buf.Write(bytes.TrimRightFunc(p, unicode.IsSpace))
+}
+
+func (w *SyslogWriter) isStream() bool {
+	switch w.network {
+	case "tcp", "tls", "unix":
+		return true
+	default:
+		return false
+	}
+}
+
+// dial opens a new connection to the server.
+func (w *SyslogWriter) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: w.timeout}
+
+	if w.network == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", w.address, w.tls)
+	}
+
+	return dialer.Dial(w.network, w.address)
+}
+
+// startReconnect starts reconnecting in the background, unless that's
+// already happening. The lock must be held.
+func (w *SyslogWriter) startReconnect() {
+	if w.reconnecting || w.closed {
+		return
+	}
+
+	w.reconnecting = true
+	go w.reconnect()
+}
+
+// reconnect dials the server until it succeeds or the writer is closed,
+// backing off between attempts.
+func (w *SyslogWriter) reconnect() {
+	delay := w.reconnectInterval
+
+	for {
+		select {
+		case <-time.After(delay):
+		case <-w.done:
+			return
+		}
+
+		conn, err := w.dial()
+
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+		if err == nil {
+			w.conn = conn
+			w.reconnecting = false
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+
+		if delay *= 2; delay > time.Minute {
+			delay = time.Minute
+		}
+	}
+}
+
+// syslogSeverity maps a Level to a syslog severity. Registered levels between
+// Info and Warn, such as a Notice level, are sent as notice.
+func syslogSeverity(level Level) int {
+	switch level {
+	case NoLevel, Off:
+		return 6
+	}
+
+	switch severity := levelSeverity(level); {
+	case severity >= levelSeverity(Panic):
+		return 2
+	case severity >= levelSeverity(Error):
+		return 3
+	case severity >= levelSeverity(Warn):
+		return 4
+	case severity > levelSeverity(Info):
+		return 5
+	case severity == levelSeverity(Info):
+		return 6
+	default:
+		return 7
+	}
+}
+
+// syslogHeaderField returns s as a valid header field, which is limited to
+// printable ASCII without spaces, up to max characters. An empty field is
+// sent as the NILVALUE.
+func syslogHeaderField(s string, max int) string {
+	if s == "" {
+		return "-"
+	}
+
+	b := []byte(s)
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+
+	if len(b) > max {
+		b = b[:max]
+	}
+
+	return string(b)
+}
+
+// writeSyslogParamValue writes s escaped for use as a PARAM-VALUE.
+func writeSyslogParamValue(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '"', '\\', ']':
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(r)
+	}
+}
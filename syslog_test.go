@@ -0,0 +1,245 @@
+package hclog
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readSyslogFrame reads a single octet-counted message.
+func readSyslogFrame(t *testing.T, r *bufio.Reader) string {
+	size, err := r.ReadString(' ')
+	require.NoError(t, err)
+
+	n, err := strconv.Atoi(strings.TrimSuffix(size, " "))
+	require.NoError(t, err)
+
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	require.NoError(t, err)
+
+	return string(buf)
+}
+
+func TestSyslogWriter(t *testing.T) {
+	testTime := time.Date(2022, 5, 10, 12, 30, 15, 123456789, time.UTC)
+	pid := strconv.Itoa(os.Getpid())
+
+	t.Run("sends messages over udp", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		w, err := NewSyslogWriter(&SyslogOptions{
+			Network:  "udp",
+			Address:  conn.LocalAddr().String(),
+			Hostname: "myhost",
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		w.now = func() time.Time { return testTime }
+
+		logger := New(&LoggerOptions{
+			Name:        "test",
+			Output:      w,
+			Level:       Trace,
+			DisableTime: true,
+		})
+
+		buf := make([]byte, 1024)
+		read := func() string {
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			n, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			return string(buf[:n])
+		}
+
+		logger.Info("this is test", "who", "programmer")
+		assert.Equal(t, "<14>1 2022-05-10T12:30:15.123456Z myhost test "+pid+" - - [INFO]  test: this is test: who=programmer", read())
+
+		logger.Error("this is bad")
+		assert.Equal(t, "<11>1 2022-05-10T12:30:15.123456Z myhost test "+pid+" - - [ERROR] test: this is bad", read())
+
+		logger.Warn("careful")
+		assert.True(t, strings.HasPrefix(read(), "<12>1 "))
+
+		logger.Debug("details")
+		assert.True(t, strings.HasPrefix(read(), "<15>1 "))
+
+		logger.Trace("more details")
+		assert.True(t, strings.HasPrefix(read(), "<15>1 "))
+	})
+
+	t.Run("sends the logger name as structured data with an app name", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		w, err := NewSyslogWriter(&SyslogOptions{
+			Network:  "udp",
+			Address:  conn.LocalAddr().String(),
+			Hostname: "myhost",
+			AppName:  "myapp",
+			Facility: SyslogLocal0,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		w.now = func() time.Time { return testTime }
+
+		logger := New(&LoggerOptions{
+			Name:        "test",
+			Output:      w,
+			DisableTime: true,
+		}).Named(`sub"sys`)
+
+		logger.Info("this is test")
+
+		buf := make([]byte, 1024)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+
+		assert.Equal(t, `<134>1 2022-05-10T12:30:15.123456Z myhost myapp `+pid+` - [hclog@32473 module="test.sub\"sys"] [INFO]  test.sub"sys: this is test`, string(buf[:n]))
+	})
+
+	t.Run("uses octet counting over tcp", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		w, err := NewSyslogWriter(&SyslogOptions{
+			Network:  "tcp",
+			Address:  ln.Addr().String(),
+			Hostname: "myhost",
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		w.now = func() time.Time { return testTime }
+
+		conn, err := ln.Accept()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		logger := New(&LoggerOptions{
+			Output:      w,
+			DisableTime: true,
+		})
+
+		logger.Info("this is test", "lines", "one\ntwo")
+		logger.Warn("this is another test")
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		r := bufio.NewReader(conn)
+
+		prefix := "<14>1 2022-05-10T12:30:15.123456Z myhost " + syslogHeaderField(filepath.Base(os.Args[0]), 48) + " " + pid + " - - "
+		assert.Equal(t, prefix+"[INFO]  this is test:\n  lines=\n  | one\n  | two", readSyslogFrame(t, r))
+
+		prefix = "<12>1" + strings.TrimPrefix(prefix, "<14>1")
+		assert.Equal(t, prefix+"[WARN]  this is another test", readSyslogFrame(t, r))
+	})
+
+	t.Run("reconnects on failure", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "hclog-syslog")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		path := filepath.Join(dir, "syslog.sock")
+
+		listen := func() net.Listener {
+			os.Remove(path)
+			ln, err := net.Listen("unix", path)
+			require.NoError(t, err)
+			return ln
+		}
+
+		ln := listen()
+
+		w, err := NewSyslogWriter(&SyslogOptions{
+			Network:           "unix",
+			Address:           path,
+			ReconnectInterval: 10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		defer w.Close()
+
+		conn, err := ln.Accept()
+		require.NoError(t, err)
+
+		_, err = w.LevelWrite(Info, []byte("first\n"))
+		require.NoError(t, err)
+
+		r := bufio.NewReader(conn)
+		assert.True(t, strings.HasSuffix(readSyslogFrame(t, r), " - - first"))
+
+		// Take the server away, so that the writes fail.
+		conn.Close()
+		ln.Close()
+
+		require.Eventually(t, func() bool {
+			_, err := w.LevelWrite(Info, []byte("lost\n"))
+			return err != nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// Messages are dropped, without waiting, until the connection has
+		// been reopened in the background.
+		start := time.Now()
+		for i := 0; i < 100; i++ {
+			_, err = w.LevelWrite(Info, []byte("lost\n"))
+			assert.Error(t, err)
+		}
+		assert.True(t, time.Since(start) < time.Second)
+		assert.True(t, w.Dropped() >= 101)
+
+		ln = listen()
+		defer ln.Close()
+
+		require.Eventually(t, func() bool {
+			_, err := w.LevelWrite(Info, []byte("second\n"))
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		conn, err = ln.Accept()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		r = bufio.NewReader(conn)
+		assert.True(t, strings.HasSuffix(readSyslogFrame(t, r), " - - second"))
+	})
+
+	t.Run("errors after close", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		w, err := NewSyslogWriter(&SyslogOptions{
+			Network: "udp",
+			Address: conn.LocalAddr().String(),
+		})
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		_, err = w.Write([]byte("closed\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unknown networks", func(t *testing.T) {
+		_, err := NewSyslogWriter(&SyslogOptions{
+			Network: "carrier-pigeon",
+		})
+		assert.Error(t, err)
+	})
+}
@@ -15,17 +15,19 @@ func newWriter(w io.Writer, color ColorOption) *writer {
 	return &writer{w: w, color: color}
 }
 
-func (w *writer) Flush(level Level) (err error) {
+func (w *writer) Flush(name string, level Level) (err error) {
 	var unwritten = w.b.Bytes()
 
-	if w.color != ColorOff {
-		color := _levelToColor[level]
+	if color := levelColor(level); color != nil && w.color != ColorOff {
 		unwritten = []byte(color.Sprintf("%s", unwritten))
 	}
 
-	if lw, ok := w.w.(LevelWriter); ok {
+	switch lw := w.w.(type) {
+	case NamedLevelWriter:
+		_, err = lw.NamedLevelWrite(name, level, unwritten)
+	case LevelWriter:
 		_, err = lw.LevelWrite(level, unwritten)
-	} else {
+	default:
 		_, err = w.w.Write(unwritten)
 	}
 	w.b.Reset()
@@ -49,6 +51,13 @@ type LevelWriter interface {
 	LevelWrite(level Level, p []byte) (n int, err error)
 }
 
+// NamedLevelWriter is the interface that wraps the NamedLevelWrite method.
+// It's used instead of LevelWriter when the output also needs the name of
+// the logger the message came from.
+type NamedLevelWriter interface {
+	NamedLevelWrite(name string, level Level, p []byte) (n int, err error)
+}
+
 // LeveledWriter writes all log messages to the standard writer,
 // except for log levels that are defined in the overrides map.
 type LeveledWriter struct {